@@ -0,0 +1,220 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"strings"
+
+	"dynamic-pricing-tool-ru/internal/types"
+)
+
+// catalog генерирует синтетические предложения. Для одного MPN и seed
+// ответ всегда одинаковый, поэтому результаты прогонов можно сравнивать.
+type catalog struct {
+	seed     int64
+	missRate float64
+	maxRows  int
+}
+
+var (
+	brands   = []string{"Texas Instruments", "STMicroelectronics", "Analog Devices", "NXP", "Microchip", "Infineon", "onsemi", "Murata"}
+	packages = []string{"SOIC-8", "SOT-23", "QFN-32", "TSSOP-20", "0603", "0805", "LQFP-64", "DIP-8"}
+	sellers  = []string{"Элитан", "Чип и Дип", "Compel", "Платан", "Symmetron", "Терраэлектроника", "ЭкоДеталь", "Радиокомплект"}
+	cities   = []string{"Москва", "Санкт-Петербург", "Новосибирск", "Екатеринбург", "Казань"}
+)
+
+func (c *catalog) rand(supplier, mpn string) *rand.Rand {
+	h := fnv.New64a()
+	h.Write([]byte(supplier + "|" + strings.ToUpper(mpn)))
+	return rand.New(rand.NewSource(c.seed ^ int64(h.Sum64())))
+}
+
+// found решает, есть ли MPN у поставщика. Решение зависит только от MPN,
+// чтобы «ненайденные» позиции совпадали у всех трёх поставщиков.
+func (c *catalog) found(mpn string) bool {
+	return c.rand("", mpn).Float64() >= c.missRate
+}
+
+func (c *catalog) rows(r *rand.Rand) int {
+	return 1 + r.Intn(c.maxRows)
+}
+
+// priceBreaks — ступени с убывающей ценой от base.
+func priceBreaks(r *rand.Rand, base float64) [][2]float64 {
+	qtys := []int{1, 10, 100, 1000, 5000}
+	n := 2 + r.Intn(len(qtys)-1)
+
+	var result [][2]float64
+	price := base
+	for _, q := range qtys[:n] {
+		result = append(result, [2]float64{float64(q), round(price)})
+		price *= 0.75 + r.Float64()*0.2
+	}
+	return result
+}
+
+func round(v float64) float64 {
+	return float64(int64(v*10000+0.5)) / 10000
+}
+
+func (c *catalog) getchips(mpn string) types.GetchipsResponse {
+	var resp types.GetchipsResponse
+	if !c.found(mpn) {
+		return resp
+	}
+
+	r := c.rand("getchips", mpn)
+	brand := brands[r.Intn(len(brands))]
+
+	for i, n := 0, c.rows(r); i < n; i++ {
+		item := struct {
+			Title         string             `json:"title"`
+			Quantity      int                `json:"quantity"`
+			SPack         int                `json:"sPack"`
+			DonorID       interface{}        `json:"donorID"`
+			Donor         string             `json:"donor"`
+			Folddivision  int                `json:"folddivision"`
+			Minq          int                `json:"minq"`
+			Brand         string             `json:"brand"`
+			Orderdays     int                `json:"orderdays"`
+			Price         float64            `json:"price"`
+			EQuantity     int                `json:"eQuantity"`
+			SearchWord    string             `json:"search_word"`
+			Currency      int                `json:"currency"`
+			Match         int                `json:"match"`
+			PriceBreak    []types.PriceBreak `json:"priceBreak"`
+			QuantityPrice float64            `json:"quantityPrice"`
+			Packaging     string             `json:"packaging"`
+		}{
+			Title:        strings.ToUpper(mpn),
+			Quantity:     r.Intn(50000),
+			SPack:        1,
+			DonorID:      1000 + r.Intn(9000),
+			Donor:        fmt.Sprintf("donor-%d", r.Intn(100)),
+			Folddivision: 1,
+			Minq:         1,
+			Brand:        brand,
+			Orderdays:    7 + r.Intn(35),
+			Currency:     2,
+			Match:        1,
+			SearchWord:   mpn,
+			Packaging:    packages[r.Intn(len(packages))],
+		}
+
+		for _, pb := range priceBreaks(r, 0.05+r.Float64()*20) {
+			item.PriceBreak = append(item.PriceBreak, types.PriceBreak{
+				Quantity: int(pb[0]),
+				Price:    pb[1],
+			})
+		}
+		item.Price = item.PriceBreak[0].Price
+		item.EQuantity = item.Quantity
+
+		resp.Data = append(resp.Data, item)
+	}
+
+	return resp
+}
+
+// efind возвращает ответ в виде дерева map/slice: его кодирует
+// writeJSON5 с теми же особенностями синтаксиса, что у настоящего Efind.
+func (c *catalog) efind(mpn string) []interface{} {
+	result := []interface{}{}
+	if !c.found(mpn) {
+		return result
+	}
+
+	r := c.rand("efind", mpn)
+
+	for i, n := 0, c.rows(r); i < n; i++ {
+		seller := sellers[r.Intn(len(sellers))]
+
+		var prices []interface{}
+		for _, pb := range priceBreaks(r, 0.05+r.Float64()*20) {
+			// кортеж [количество, валюта, цена]; цену формат берёт из третьего элемента
+			prices = append(prices, []interface{}{int(pb[0]), "usd", pb[1]})
+		}
+
+		stock := r.Intn(20000)
+
+		result = append(result, map[string]interface{}{
+			"filial": nil,
+			"finish": round(r.Float64()),
+			"rows": []interface{}{
+				map[string]interface{}{
+					"part":    strings.ToUpper(mpn),
+					"cur":     "USD",
+					"instock": stock > 0,
+					"price":   prices,
+					"moq":     1,
+					"mpq":     fmt.Sprint(1 + r.Intn(10)),
+					"stock":   fmt.Sprint(stock),
+					"od":      nil,
+				},
+			},
+			"stock_id": 100 + i,
+			"stockdata": map[string]interface{}{
+				"city":           cities[r.Intn(len(cities))],
+				"contact_email":  "sales@example.ru",
+				"contact_phones": []interface{}{"+7 495 000-00-00"},
+				"country":        "Россия",
+				"min_order":      "",
+				"region_id":      "77",
+				"site":           "https://example.ru",
+				"title":          seller,
+				"title_en":       seller,
+			},
+		})
+	}
+
+	return result
+}
+
+func (c *catalog) promelec(mpn string) []interface{} {
+	result := []interface{}{}
+	if !c.found(mpn) {
+		return result
+	}
+
+	r := c.rand("promelec", mpn)
+
+	pricebreaks := func() []interface{} {
+		var list []interface{}
+		for _, pb := range priceBreaks(r, 5+r.Float64()*2000) {
+			list = append(list, map[string]interface{}{"quant": int(pb[0]), "price": pb[1]})
+		}
+		return list
+	}
+
+	var vendors []interface{}
+	for i, n := 0, r.Intn(3); i < n; i++ {
+		vendors = append(vendors, map[string]interface{}{
+			"vendor":      1 + r.Intn(50),
+			"quant":       r.Intn(10000),
+			"delivery":    5 + r.Intn(30),
+			"pricebreaks": pricebreaks(),
+		})
+	}
+
+	item := map[string]interface{}{
+		"item_id":       100000 + r.Intn(900000),
+		"name":          strings.ToUpper(mpn),
+		"producer_name": brands[r.Intn(len(brands))],
+		"class2_id":     1 + r.Intn(500),
+		"class2name":    "Микросхемы",
+		"description":   "Синтетическая позиция mocksuppliers",
+		"photo_url":     "",
+		"package":       packages[r.Intn(len(packages))],
+		"quant":         r.Intn(5000),
+		"moq":           1,
+		"munit":         "шт",
+		"delivery_time": "",
+		"pricebreaks":   pricebreaks(),
+	}
+	if len(vendors) > 0 {
+		item["vendors"] = vendors
+	}
+
+	return append(result, item)
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"sort"
+)
+
+var identifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// writeJSON5 кодирует значение так, как отвечает Efind: ключи без кавычек
+// и висячие запятые в объектах и массивах. Обычный JSON-декодер такой
+// ответ не разберёт, поэтому клиент использует json5.
+func writeJSON5(buf *bytes.Buffer, v interface{}) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		buf.WriteByte('{')
+		for _, k := range keys {
+			if identifier.MatchString(k) {
+				buf.WriteString(k)
+			} else {
+				writeJSON5(buf, k)
+			}
+			buf.WriteString(": ")
+			writeJSON5(buf, val[k])
+			buf.WriteByte(',')
+		}
+		buf.WriteByte('}')
+	case []interface{}:
+		buf.WriteByte('[')
+		for _, item := range val {
+			writeJSON5(buf, item)
+			buf.WriteByte(',')
+		}
+		buf.WriteByte(']')
+	default:
+		data, err := json.Marshal(val)
+		if err != nil {
+			data = []byte("null")
+		}
+		buf.Write(data)
+	}
+}
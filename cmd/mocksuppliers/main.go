@@ -0,0 +1,163 @@
+// mocksuppliers эмулирует API Getchips, Efind и Promelec для нагрузочного
+// и интеграционного тестирования без расхода квот поставщиков.
+//
+// Сервер отвечает по тем же путям, что вызывают клиенты, поэтому
+// достаточно направить на него URL поставщиков:
+//
+//	GETCHIPS_URL=http://localhost:8090/client/api/gh/v1/search/partnumber
+//	EFIND_URL=http://localhost:8090/api/search
+//	PROMELEC_URL=http://localhost:8090/rpc/
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"log"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// faults — задержка и сбои, которые добавляются к каждому ответу.
+type faults struct {
+	latency       time.Duration
+	jitter        time.Duration
+	errorRate     float64
+	rateLimitRate float64
+
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+func (f *faults) float() float64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.rnd.Float64()
+}
+
+func (f *faults) middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		delay := f.latency
+		if f.jitter > 0 {
+			delay += time.Duration(f.float() * float64(f.jitter))
+		}
+
+		if delay > 0 {
+			select {
+			case <-time.After(delay):
+			case <-c.Request.Context().Done():
+				c.Abort()
+				return
+			}
+		}
+
+		if f.rateLimitRate > 0 && f.float() < f.rateLimitRate {
+			c.Header("Retry-After", "1")
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "too many requests"})
+			return
+		}
+
+		if f.errorRate > 0 && f.float() < f.errorRate {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "injected failure"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func main() {
+	addr := flag.String("addr", ":8090", "listen address")
+	latency := flag.Duration("latency", 100*time.Millisecond, "base response latency")
+	jitter := flag.Duration("jitter", 100*time.Millisecond, "random extra latency up to this value")
+	errorRate := flag.Float64("error-rate", 0, "share of requests answered with 500 (0..1)")
+	rateLimitRate := flag.Float64("429-rate", 0, "share of requests answered with 429 (0..1)")
+	missRate := flag.Float64("miss-rate", 0.1, "share of part numbers not found at any supplier (0..1)")
+	maxRows := flag.Int("max-offers", 5, "maximum offers per supplier for one part number")
+	seed := flag.Int64("seed", 1, "catalog seed; the same seed gives the same offers")
+	flag.Parse()
+
+	if *maxRows < 1 {
+		log.Fatal("-max-offers must be at least 1")
+	}
+
+	cat := &catalog{
+		seed:     *seed,
+		missRate: *missRate,
+		maxRows:  *maxRows,
+	}
+
+	f := &faults{
+		latency:       *latency,
+		jitter:        *jitter,
+		errorRate:     *errorRate,
+		rateLimitRate: *rateLimitRate,
+		rnd:           rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(gin.Recovery())
+
+	suppliers := router.Group("/", f.middleware())
+
+	// Getchips: GET ?input=<mpn>&qty=<n>&token=<token>
+	suppliers.GET("/client/api/gh/v1/search/partnumber", func(c *gin.Context) {
+		if c.Query("token") == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "token is required"})
+			return
+		}
+
+		c.JSON(http.StatusOK, cat.getchips(c.Query("input")))
+	})
+
+	// Efind: GET /api/search/<mpn>?access_token=...; ответ в JSON5
+	suppliers.GET("/api/search/:mpn", func(c *gin.Context) {
+		if c.Query("access_token") == "" {
+			c.String(http.StatusUnauthorized, "access_token is required")
+			return
+		}
+
+		var buf bytes.Buffer
+		writeJSON5(&buf, cat.efind(c.Param("mpn")))
+		c.Data(http.StatusOK, "application/json; charset=utf-8", buf.Bytes())
+	})
+
+	// Promelec: POST JSON {"login","password","method":"items_data_find","name"}
+	suppliers.POST("/rpc/", func(c *gin.Context) {
+		var req struct {
+			Login    string `json:"login"`
+			Password string `json:"password"`
+			Method   string `json:"method"`
+			Name     string `json:"name"`
+		}
+
+		if err := json.NewDecoder(c.Request.Body).Decode(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		// ошибки RPC приходят конвертом с кодом 200, как у настоящего сервиса
+		if req.Login == "" || req.Password == "" {
+			c.JSON(http.StatusOK, gin.H{"error": gin.H{"code": 401, "message": "authorization failed"}})
+			return
+		}
+		if req.Method != "items_data_find" {
+			c.JSON(http.StatusOK, gin.H{"error": "unknown method " + req.Method, "code": 404})
+			return
+		}
+
+		c.JSON(http.StatusOK, cat.promelec(strings.TrimSpace(req.Name)))
+	})
+
+	log.Printf("mocksuppliers listening on %s (latency %s+%s, errors %.0f%%, 429 %.0f%%)",
+		*addr, *latency, *jitter, *errorRate*100, *rateLimitRate*100)
+
+	if err := http.ListenAndServe(*addr, router); err != nil {
+		log.Fatal(err)
+	}
+}
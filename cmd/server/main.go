@@ -1,60 +1,351 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"regexp"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 	"go.uber.org/zap"
 
 	"dynamic-pricing-tool-ru/internal/api"
 	"dynamic-pricing-tool-ru/internal/config"
+	"dynamic-pricing-tool-ru/internal/fixtures"
 	"dynamic-pricing-tool-ru/internal/logger"
+	"dynamic-pricing-tool-ru/internal/metrics"
 	"dynamic-pricing-tool-ru/internal/processor"
 	"dynamic-pricing-tool-ru/internal/server"
+	"dynamic-pricing-tool-ru/internal/storage"
+	"dynamic-pricing-tool-ru/internal/tracing"
+	"dynamic-pricing-tool-ru/internal/watch"
 )
 
 func main() {
-	if err := logger.Init("logs"); err != nil {
+	// код выхода выставляется в самом конце, после всех отложенных очисток
+	exitCode := 0
+	defer func() {
+		if exitCode != 0 {
+			os.Exit(exitCode)
+		}
+	}()
+
+	// .env читаем до инициализации логгера: уровень и выходы задаются в нём
+	envErr := godotenv.Load()
+
+	cfg, err := config.Load()
+	if err != nil {
+		// логгер ещё не настроен: его параметры тоже берутся из конфига
+		fmt.Fprintf(os.Stderr, "Invalid configuration:\n%v\n", err)
+		os.Exit(1)
+	}
+
+	if err := logger.Init(logger.Options{
+		Level:            cfg.LogLevel,
+		Output:           cfg.LogOutput,
+		Dir:              cfg.LogDir,
+		BodyCapture:      cfg.LogBodyCapture,
+		BodySampleRate:   cfg.LogBodySampleRate,
+		MaxBodySize:      cfg.LogMaxBodySize,
+		AllowDebugHeader: cfg.LogAllowDebugHeader,
+	}); err != nil {
 		panic(err)
 	}
 	defer logger.L.Sync()
 
-	if err := godotenv.Load(); err != nil {
+	if envErr != nil {
 		logger.L.Info("No .env file found, using environment variables")
 	}
 
-	cfg := config.LoadConfig()
+	logger.SetRedactor(logger.NewRedactor(
+		cfg.RedactQueryParams,
+		cfg.RedactBodyFields,
+		cfg.Secrets(),
+	))
+
+	for _, problem := range cfg.Problems() {
+		logger.L.Warn("Config problem",
+			zap.String("problem", problem))
+	}
+
+	shutdownTracing, err := tracing.Init(context.Background(), cfg.TracesExporter, cfg.ServiceName)
+	if err != nil {
+		logger.L.Fatal("Failed to init tracing",
+			zap.Error(err))
+	}
+	defer shutdownTracing(context.Background())
+
+	supplierTransport, err := fixtures.Transport(cfg.SupplierFixtures, cfg.SupplierFixturesDir, http.DefaultTransport)
+	if err != nil {
+		logger.L.Fatal("Failed to set up supplier fixtures",
+			zap.Error(err))
+	}
+	api.SetTransport(supplierTransport)
 
-	if cfg.GetchipsToken == "" {
-		logger.L.Fatal("GETCHIPS_TOKEN is required. Set it in .env file or environment variable")
+	if cfg.SupplierFixtures == fixtures.ModeReplay {
+		logger.L.Warn("Supplier responses are replayed from fixtures, live APIs are not called",
+			zap.String("dir", cfg.SupplierFixturesDir))
 	}
 
-	if cfg.EfindToken == "" {
-		logger.L.Fatal("EFIND_TOKEN is required. Set it in .env file or environment variable")
+	suppliers := cfg.Suppliers
+	getchipsClient := api.NewGetchipsClient(suppliers.Getchips.URL, suppliers.Getchips.Token, suppliers.Getchips.Timeout)
+	efindClient := api.NewEfindClient(suppliers.Efind.URL, suppliers.Efind.Token, suppliers.Efind.Timeout)
+	promelecClient := api.NewPromelecClient(suppliers.Promelec.URL, suppliers.Promelec.Login, suppliers.Promelec.Password, suppliers.Promelec.Timeout)
+
+	db, err := storage.Open(cfg.DatabasePath)
+	if err != nil {
+		logger.L.Fatal("Failed to open database",
+			zap.String("path", cfg.DatabasePath),
+			zap.Error(err))
 	}
+	defer db.Close()
 
-	getchipsClient := api.NewGetchipsClient(cfg.GetchipsURL, cfg.GetchipsToken)
-	efindClient := api.NewEfindClient(cfg.EfindURL, cfg.EfindToken)
-	promelecClient := api.NewPromelecClient(cfg.PromelecURL, cfg.PromelecLogin, cfg.PromelecPass)
+	history := storage.NewPriceHistory(db, cfg.HistoryBuffer)
+	defer history.Close()
 
 	proc := processor.NewProcessorWithClients(getchipsClient, efindClient, promelecClient, cfg.ChunkSize)
+	proc.SetHistory(history)
+
+	sellerRules := storage.NewSellerRules(db)
+	rules, err := sellerRules.List(context.Background())
+	if err != nil {
+		logger.L.Fatal("Failed to load seller rules", zap.Error(err))
+	}
+	proc.SetSellerRules(rules)
+
+	limits := processor.Limits{
+		MaxRows:      cfg.MaxBOMRows,
+		MaxMPNLength: cfg.MaxMPNLength,
+		PackSizes: map[string]int{
+			processor.PackReel: cfg.ReelSize,
+			processor.PackTray: cfg.TraySize,
+		},
+	}
+	if cfg.MPNAllowedPattern != "" {
+		// шаблон уже проверен в config.Validate
+		limits.AllowedMPN = regexp.MustCompile(cfg.MPNAllowedPattern)
+	}
+	proc.SetLimits(limits)
+
+	// всё, что можно менять без перезапуска, применяется через одну функцию:
+	// при старте и при каждой перезагрузке конфигурации. Новые значения
+	// собираются заранее и подменяются целиком: политики всех поставщиков —
+	// одним набором, цены — одним указателем, который запрос фиксирует
+	// у себя при старте (processor.WithPricing)
+	applyConfig := func(cfg config.Config) {
+		policies := map[string]api.Policy{
+			api.SupplierGetchips: supplierPolicy(cfg.Suppliers.Getchips),
+			api.SupplierEfind:    supplierPolicy(cfg.Suppliers.Efind),
+			api.SupplierPromelec: supplierPolicy(cfg.Suppliers.Promelec),
+		}
+		prices := pricing(cfg)
+
+		// уровень уже проверен в config.Validate
+		if err := logger.SetLevel(cfg.LogLevel); err != nil {
+			logger.L.Warn("Invalid log level", zap.Error(err))
+		}
+		proc.CombinedClient().SetPolicies(policies)
+		proc.SetPricing(prices)
+	}
+	applyConfig(cfg)
+
+	reloader := config.NewReloader(cfg, applyConfig)
+
+	// workCtx — родительский контекст всех запросов и фоновых задач; его
+	// отмена прерывает то, что не успело завершиться за время дренажа
+	// background — фоновые задачи, пишущие в базу; отложенный Wait
+	// выполняется после cancelWork и до закрытия истории и базы
+	var background sync.WaitGroup
+	defer background.Wait()
+
+	workCtx, cancelWork := context.WithCancel(context.Background())
+	defer cancelWork()
+
+	watchlist := storage.NewWatchlist(db)
+	customers := storage.NewCustomers(db)
+	usage := storage.NewUsage(db)
+
+	if cfg.WatchInterval > 0 {
+		var notifier *watch.WebhookNotifier
+		if cfg.WebhookURL != "" {
+			notifier = watch.NewWebhookNotifier(cfg.WebhookURL, cfg.WebhookSecret)
+		} else {
+			logger.L.Warn("WATCH_WEBHOOK_URL is not set, watch alerts will only be logged")
+		}
+
+		scheduler := watch.NewScheduler(watchlist, proc, notifier, cfg.WatchInterval)
+		scheduler.SetQuota(customers, usage)
+
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			scheduler.Run(workCtx)
+		}()
+	}
 
-	handler := server.NewHandler(proc)
+	quotes := storage.NewQuotes(db)
+	crossRefs := storage.NewCrossRefs(db)
+	proc.SetCrossRefs(crossRefs)
+
+	handler := server.NewHandler(proc, history, watchlist, quotes, customers, cfg.QuoteValidDays)
+	handler.SetHealthConfig(cfg.HealthCanaryMPN)
+	handler.SetAuth(usage, cfg.AuthEnabled, cfg.AdminAPIKey)
+	handler.SetMaxBodyBytes(cfg.MaxBodyBytes)
+	handler.SetReloader(reloader)
+	handler.SetCrossRefs(crossRefs)
+	handler.SetSellerRules(sellerRules)
+
+	go reloader.Watch(workCtx, cfg.ConfigWatchInterval)
+	go reloadOnSIGHUP(workCtx, reloader)
 
 	router := gin.Default()
 
+	router.Use(handler.TrackInFlight())
+	router.Use(tracing.GinMiddleware())
+	router.Use(handler.ConfigVersion())
 	router.Use(logger.RequestID())
 	router.Use(logger.GinLogger())
+	router.Use(metrics.GinMiddleware())
 	router.Use(gin.Recovery())
 
-	router.POST("/api/v1/ru/process", handler.HandleProcess)
+	// заголовок отладки включает запись тел целиком, поэтому учитывается
+	// только после проверки ключа
+	v1 := router.Group("/api/v1/ru", handler.Auth(), logger.DebugOverride())
+	v1.POST("/process", handler.HandleProcess)
+	v1.GET("/history", handler.HandleHistory)
+	v1.POST("/watchlist", handler.HandleWatchAdd)
+	v1.GET("/watchlist", handler.HandleWatchList)
+	v1.DELETE("/watchlist/:id", handler.HandleWatchDelete)
+	v1.POST("/quotes", handler.HandleQuoteCreate)
+	v1.GET("/quotes/:number", handler.HandleQuoteGet)
+	v1.POST("/quotes/:number/reprice", handler.HandleQuoteReprice)
+
+	admin := router.Group("/api/v1/ru", handler.AdminAuth(), logger.DebugOverride())
+	admin.GET("/customers", handler.HandleCustomerList)
+	admin.PUT("/customers/:id", handler.HandleCustomerSave)
+	admin.DELETE("/customers/:id", handler.HandleCustomerDelete)
+	admin.GET("/customers/:id/usage", handler.HandleUsage)
+	admin.POST("/config/reload", handler.HandleConfigReload)
+	admin.POST("/crossref/import", handler.HandleCrossRefImport)
+	admin.GET("/crossref", handler.HandleCrossRefList)
+	admin.DELETE("/crossref", handler.HandleCrossRefDelete)
+	admin.GET("/sellers/rules", handler.HandleSellerRuleList)
+	admin.POST("/sellers/rules", handler.HandleSellerRuleSave)
+	admin.PUT("/sellers/rules/:id", handler.HandleSellerRuleSave)
+	admin.DELETE("/sellers/rules/:id", handler.HandleSellerRuleDelete)
+
 	router.GET("/health", handler.HealthCheck)
+	router.GET("/health/live", handler.HandleLive)
+	router.GET("/health/ready", handler.HandleReady)
+	router.GET("/health/suppliers", handler.HandleSuppliers)
+	router.GET("/metrics", metrics.Handler())
+
+	srv := &http.Server{
+		Addr:        ":" + cfg.ServerPort,
+		Handler:     router,
+		BaseContext: func(net.Listener) context.Context { return workCtx },
+	}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		logger.L.Info("Server starting",
+			zap.String("port", cfg.ServerPort),
+		)
+
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
 
-	logger.L.Info("Server starting",
-		zap.String("port", cfg.ServerPort),
+	signalCtx, stopSignals := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stopSignals()
+
+	select {
+	case err := <-serverErr:
+		logger.L.Error("Failed to start server:",
+			zap.Error(err))
+		exitCode = 1
+		return
+	case <-signalCtx.Done():
+	}
+
+	logger.L.Info("Shutdown signal received, draining",
+		zap.Duration("drain_timeout", cfg.ShutdownDrainTimeout),
 	)
 
-	if err := router.Run(":" + cfg.ServerPort); err != nil {
-		logger.L.Fatal("Failed to start server:",
+	// сначала readiness начинает отвечать ошибкой, чтобы балансировщик
+	// перестал присылать новые запросы, и только потом закрываем listener
+	handler.SetReady(false)
+	time.Sleep(cfg.ShutdownReadinessDelay)
+
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), cfg.ShutdownDrainTimeout)
+	defer cancelDrain()
+
+	if err := srv.Shutdown(drainCtx); err != nil {
+		logger.L.Warn("Drain timeout exceeded, cancelling in-flight requests",
 			zap.Error(err))
+
+		cancelWork()
+		srv.Close()
+	}
+
+	cancelWork()
+
+	// srv.Close не ждёт обработчиков: отменённые запросы ещё могут писать
+	// в историю и базу, которые закроются в отложенных вызовах
+	handler.WaitInFlight()
+
+	logger.L.Info("Server stopped")
+}
+
+func supplierPolicy(s config.SupplierConfig) api.Policy {
+	return api.Policy{
+		Enabled:       s.Enabled,
+		Timeout:       s.Timeout,
+		RetryAttempts: s.Retry.Attempts,
+		RetryBackoff:  s.Retry.Backoff,
+		RateLimit:     s.RateLimit,
+	}
+}
+
+func pricing(cfg config.Config) processor.Pricing {
+	vatIncluded := map[string]bool{}
+	for _, source := range cfg.VATIncluded {
+		vatIncluded[strings.ToLower(source)] = true
+	}
+
+	return processor.Pricing{
+		VATRate:     cfg.VATRate,
+		VATIncluded: vatIncluded,
+		Rates:       map[string]float64{"USD": cfg.USDRate},
+		MarkupCoef:  cfg.MarkupCoef,
+	}
+}
+
+// reloadOnSIGHUP перечитывает конфигурацию по SIGHUP.
+func reloadOnSIGHUP(ctx context.Context, reloader *config.Reloader) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			if _, err := reloader.Reload(); err != nil {
+				logger.L.Error("Config reload failed, keeping current config",
+					zap.Error(err))
+			}
+		}
 	}
 }
@@ -2,6 +2,13 @@ package api
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
 	"dynamic-pricing-tool-ru/internal/types"
 )
 
@@ -9,3 +16,67 @@ type APIClient interface {
 	SearchPart(ctx context.Context, partNumber string, quantity int) (*types.GetchipsResponse, error)
 	SearchPartAsync(ctx context.Context, partNumber string, quantity int, results chan<- types.APIResponse)
 }
+
+// StatusError — поставщик ответил кодом, отличным от 200.
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("API returned status: %d", e.StatusCode)
+}
+
+// ErrorClass сводит ошибку вызова поставщика к небольшому набору классов
+// для метрик и логов. Для nil возвращает пустую строку.
+func ErrorClass(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	var statusErr *StatusError
+	var rpcErr *PromelecError
+	var netErr net.Error
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.As(err, &statusErr):
+		switch {
+		case statusErr.StatusCode == http.StatusTooManyRequests:
+			return "rate_limited"
+		case statusErr.StatusCode >= 500:
+			return "http_5xx"
+		default:
+			return "http_4xx"
+		}
+	case errors.As(err, &rpcErr):
+		return "rpc_error"
+	case errors.As(err, &netErr):
+		if netErr.Timeout() {
+			return "timeout"
+		}
+		return "network"
+	case strings.Contains(err.Error(), "decode"):
+		return "decode"
+	default:
+		return "other"
+	}
+}
+
+var baseTransport atomic.Pointer[http.RoundTripper]
+
+// SetTransport задаёт транспорт, через который ходят все клиенты
+// поставщиков, например запись или воспроизведение фикстур. Действует
+// на клиентов, созданных или перенастроенных после вызова.
+func SetTransport(rt http.RoundTripper) {
+	baseTransport.Store(&rt)
+}
+
+func transport() http.RoundTripper {
+	if rt := baseTransport.Load(); rt != nil && *rt != nil {
+		return *rt
+	}
+	return http.DefaultTransport
+}
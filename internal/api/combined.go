@@ -2,15 +2,32 @@ package api
 
 import (
 	"context"
+	"errors"
 	"sync"
+	"time"
 
+	"dynamic-pricing-tool-ru/internal/metrics"
+	"dynamic-pricing-tool-ru/internal/tracing"
 	"dynamic-pricing-tool-ru/internal/types"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	SupplierGetchips = "getchips"
+	SupplierEfind    = "efind"
+	SupplierPromelec = "promelec"
 )
 
 type CombinedAPIClient struct {
 	getchips *GetchipsClient
 	efind    *EfindClient
 	promelec *PromelecClient
+
+	health map[string]*supplierHealth
+
+	mu       sync.RWMutex
+	policies map[string]*supplierPolicy
 }
 
 func NewCombinedAPIClient(getchips *GetchipsClient, efind *EfindClient, promelec *PromelecClient) *CombinedAPIClient {
@@ -18,13 +35,88 @@ func NewCombinedAPIClient(getchips *GetchipsClient, efind *EfindClient, promelec
 		getchips: getchips,
 		efind:    efind,
 		promelec: promelec,
+		health: map[string]*supplierHealth{
+			SupplierGetchips: newSupplierHealth(SupplierGetchips),
+			SupplierEfind:    newSupplierHealth(SupplierEfind),
+			SupplierPromelec: newSupplierHealth(SupplierPromelec),
+		},
+		policies: map[string]*supplierPolicy{
+			SupplierGetchips: newSupplierPolicy(DefaultPolicy()),
+			SupplierEfind:    newSupplierPolicy(DefaultPolicy()),
+			SupplierPromelec: newSupplierPolicy(DefaultPolicy()),
+		},
 	}
 }
 
+// SetPolicies задаёт политики обращения к поставщикам: включён ли он,
+// таймаут, повторы, ограничение частоты. Можно вызывать на ходу: набор
+// заменяется целиком, поэтому вызовы видят либо старые политики всех
+// поставщиков, либо новые. Поставщики, которых нет в policies, не меняются.
+func (c *CombinedAPIClient) SetPolicies(policies map[string]Policy) {
+	next := make(map[string]*supplierPolicy, len(c.policies))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for supplier, policy := range c.policies {
+		next[supplier] = policy
+	}
+
+	for supplier, policy := range policies {
+		if policy.Timeout > 0 {
+			switch supplier {
+			case SupplierGetchips:
+				c.getchips.SetTimeout(policy.Timeout)
+			case SupplierEfind:
+				c.efind.SetTimeout(policy.Timeout)
+			case SupplierPromelec:
+				c.promelec.SetTimeout(policy.Timeout)
+			}
+		}
+
+		next[supplier] = newSupplierPolicy(policy)
+	}
+
+	c.policies = next
+}
+
+func (c *CombinedAPIClient) policy(supplier string) *supplierPolicy {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.policies[supplier]
+}
+
 func (c *CombinedAPIClient) SearchPart(ctx context.Context, partNumber string, quantity int) (*types.APIResponse, error) {
 	return nil, nil
 }
 
+// call оборачивает обращение к поставщику: автомат отключения, спан,
+// повторы и ограничение частоты, метрики и статистика для /health/suppliers.
+func (c *CombinedAPIClient) call(ctx context.Context, supplier string, fn func(ctx context.Context) error) error {
+	policy := c.policy(supplier)
+	if !policy.Enabled {
+		return ErrSupplierDisabled
+	}
+
+	health := c.health[supplier]
+
+	if !health.allow() {
+		metrics.ObserveSupplierCall(supplier, 0, "circuit_open")
+		return ErrCircuitOpen
+	}
+
+	ctx, span := tracing.Start(ctx, "supplier."+supplier, attribute.String("supplier", supplier))
+	start := time.Now()
+
+	err := policy.do(ctx, fn)
+
+	metrics.ObserveSupplierCall(supplier, time.Since(start), ErrorClass(err))
+	health.record(err)
+	tracing.End(span, err)
+
+	return err
+}
+
 func (c *CombinedAPIClient) SearchAllAPIs(ctx context.Context, partNumber string, quantity int) types.APIResponse {
 	var wg sync.WaitGroup
 	var result types.APIResponse
@@ -36,25 +128,94 @@ func (c *CombinedAPIClient) SearchAllAPIs(ctx context.Context, partNumber string
 
 	go func() {
 		defer wg.Done()
-		data, err := c.getchips.SearchPart(ctx, partNumber, quantity)
-		result.GetchipsData = data
-		result.GetchipsErr = err
+		result.GetchipsErr = c.call(ctx, SupplierGetchips, func(ctx context.Context) (err error) {
+			result.GetchipsData, err = c.getchips.SearchPart(ctx, partNumber, quantity)
+			return err
+		})
 	}()
 
 	go func() {
 		defer wg.Done()
-		data, err := c.efind.SearchPart(ctx, partNumber, quantity)
-		result.EfindData = data
-		result.EfindErr = err
+		result.EfindErr = c.call(ctx, SupplierEfind, func(ctx context.Context) (err error) {
+			result.EfindData, err = c.efind.SearchPart(ctx, partNumber, quantity)
+			return err
+		})
 	}()
 
 	go func() {
 		defer wg.Done()
-		data, err := c.promelec.SearchPart(ctx, partNumber)
-		result.PromelecData = data
-		result.PromelecErr = err
+		result.PromelecErr = c.call(ctx, SupplierPromelec, func(ctx context.Context) (err error) {
+			result.PromelecData, err = c.promelec.SearchPart(ctx, partNumber)
+			return err
+		})
 	}()
 
 	wg.Wait()
 	return result
 }
+
+// SearchError объединяет ошибки поставщиков из результата поиска.
+// Отключённые поставщики ошибкой не считаются.
+func SearchError(result types.APIResponse) error {
+	var errs []error
+	for _, err := range []error{result.GetchipsErr, result.EfindErr, result.PromelecErr} {
+		if err != nil && !errors.Is(err, ErrSupplierDisabled) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Health возвращает состояние поставщиков в фиксированном порядке.
+func (c *CombinedAPIClient) Health() []types.SupplierStatus {
+	var statuses []types.SupplierStatus
+	for _, name := range []string{SupplierGetchips, SupplierEfind, SupplierPromelec} {
+		status := c.health[name].status()
+		if !c.policy(name).Enabled {
+			status.Circuit = CircuitDisabled
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// Enabled — поставщики, включённые в конфиге, в том же порядке, что и
+// Health; только их опрашивает SearchAllAPIs.
+func (c *CombinedAPIClient) Enabled() []string {
+	var names []string
+	for _, s := range c.Health() {
+		if s.Circuit != CircuitDisabled {
+			names = append(names, s.Name)
+		}
+	}
+	return names
+}
+
+// Probe выполняет пробный запрос канареечного MPN к каждому поставщику.
+// Результат учитывается в статистике так же, как обычный вызов.
+func (c *CombinedAPIClient) Probe(ctx context.Context, mpn string) map[string]types.ProbeResult {
+	results := map[string]types.ProbeResult{}
+
+	started := time.Now()
+	apiResult := c.SearchAllAPIs(ctx, mpn, 1)
+	latency := time.Since(started)
+
+	set := func(name string, err error, found bool) {
+		r := types.ProbeResult{
+			MPN:       mpn,
+			OK:        err == nil,
+			Found:     found,
+			LatencyMs: latency.Milliseconds(),
+		}
+		if err != nil {
+			r.Error = err.Error()
+		}
+		results[name] = r
+	}
+
+	set(SupplierGetchips, apiResult.GetchipsErr, apiResult.GetchipsData != nil && len(apiResult.GetchipsData.Data) > 0)
+	set(SupplierEfind, apiResult.EfindErr, apiResult.EfindData != nil && len(*apiResult.EfindData) > 0)
+	set(SupplierPromelec, apiResult.PromelecErr, len(apiResult.PromelecData) > 0)
+
+	return results
+}
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"sync/atomic"
 	"time"
 
 	"dynamic-pricing-tool-ru/internal/logger"
@@ -20,18 +21,24 @@ import (
 type EfindClient struct {
 	baseURL     string
 	accessToken string
-	client      *http.Client
+	client      atomic.Pointer[http.Client]
 }
 
-func NewEfindClient(baseURL, accessToken string) *EfindClient {
-	return &EfindClient{
+func NewEfindClient(baseURL, accessToken string, timeout time.Duration) *EfindClient {
+	c := &EfindClient{
 		baseURL:     baseURL,
 		accessToken: accessToken,
-		client: &http.Client{
-			Transport: logger.NewLoggingRoundTripper(nil),
-			Timeout:   30 * time.Second,
-		},
 	}
+	c.SetTimeout(timeout)
+	return c
+}
+
+// SetTimeout заменяет HTTP-клиент; запросы в полёте дорабатывают со старым.
+func (c *EfindClient) SetTimeout(timeout time.Duration) {
+	c.client.Store(&http.Client{
+		Transport: logger.NewLoggingRoundTripper(transport()),
+		Timeout:   timeout,
+	})
 }
 
 func (c *EfindClient) SearchPart(ctx context.Context, partNumber string, quantity int) (*types.EfindResponse, error) {
@@ -62,14 +69,14 @@ func (c *EfindClient) SearchPart(ctx context.Context, partNumber string, quantit
 	req.Header.Set("Accept-Language", "ru-RU,ru;q=0.9,en-US;q=0.8,en;q=0.7")
 	req.Header.Set("Connection", "keep-alive")
 
-	resp, err := c.client.Do(req)
+	resp, err := c.client.Load().Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, fmt.Errorf("request failed: %w", logger.RedactError(err))
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status: %d", resp.StatusCode)
+		return nil, &StatusError{StatusCode: resp.StatusCode}
 	}
 
 	body, _ := io.ReadAll(resp.Body)
@@ -77,14 +84,16 @@ func (c *EfindClient) SearchPart(ctx context.Context, partNumber string, quantit
 	trim := bytes.TrimSpace(body)
 
 	if len(trim) == 0 || trim[0] != '[' {
-		return nil, fmt.Errorf("efind returned non-json response: %s", string(trim[:200]))
+		if len(trim) > 200 {
+			trim = trim[:200]
+		}
+		return nil, fmt.Errorf("efind returned non-json response: %s", string(trim))
 	}
 
 	var result types.EfindResponse
 	if err := json5.Unmarshal(trim, &result); err != nil {
-		logger.L.Error("DECODE ERROR",
-			zap.ByteString("raw", trim),
-
+		logger.FromContext(ctx).Error("DECODE ERROR",
+			logger.BodyField(ctx, "raw", trim, true),
 			zap.Error(err),
 		)
 		return nil, fmt.Errorf("failed to decode response: %w", err)
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"sync/atomic"
 	"time"
 
 	"dynamic-pricing-tool-ru/internal/logger"
@@ -19,18 +20,24 @@ import (
 type GetchipsClient struct {
 	baseURL string
 	token   string
-	client  *http.Client
+	client  atomic.Pointer[http.Client]
 }
 
-func NewGetchipsClient(baseURL, token string) *GetchipsClient {
-	return &GetchipsClient{
+func NewGetchipsClient(baseURL, token string, timeout time.Duration) *GetchipsClient {
+	c := &GetchipsClient{
 		baseURL: baseURL,
 		token:   token,
-		client: &http.Client{
-			Transport: logger.NewLoggingRoundTripper(nil),
-			Timeout:   30 * time.Second,
-		},
 	}
+	c.SetTimeout(timeout)
+	return c
+}
+
+// SetTimeout заменяет HTTP-клиент; запросы в полёте дорабатывают со старым.
+func (c *GetchipsClient) SetTimeout(timeout time.Duration) {
+	c.client.Store(&http.Client{
+		Transport: logger.NewLoggingRoundTripper(transport()),
+		Timeout:   timeout,
+	})
 }
 
 func (c *GetchipsClient) SearchPart(ctx context.Context, partNumber string, quantity int) (*types.GetchipsResponse, error) {
@@ -56,14 +63,14 @@ func (c *GetchipsClient) SearchPart(ctx context.Context, partNumber string, quan
 	req.Header.Set("Connection", "keep-alive")
 	req.Header.Set("Referer", "https://getchips.com/")
 
-	resp, err := c.client.Do(req)
+	resp, err := c.client.Load().Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, fmt.Errorf("request failed: %w", logger.RedactError(err))
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status: %d", resp.StatusCode)
+		return nil, &StatusError{StatusCode: resp.StatusCode}
 	}
 
 	respBytes, err := io.ReadAll(resp.Body)
@@ -73,8 +80,8 @@ func (c *GetchipsClient) SearchPart(ctx context.Context, partNumber string, quan
 
 	var result types.GetchipsResponse
 	if err := json5.Unmarshal(respBytes, &result); err != nil {
-		logger.L.Error("DECODE ERROR",
-			zap.ByteString("raw", respBytes),
+		logger.FromContext(ctx).Error("DECODE ERROR",
+			logger.BodyField(ctx, "raw", respBytes, true),
 			zap.Error(err),
 		)
 		return nil, fmt.Errorf("failed to decode response: %w", err)
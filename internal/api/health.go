@@ -0,0 +1,166 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"dynamic-pricing-tool-ru/internal/types"
+)
+
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half_open"
+	CircuitDisabled = "disabled"
+)
+
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// Параметры автомата: по последним healthWindow вызовам, если ошибок не
+// меньше circuitErrorRate и вызовов хотя бы circuitMinSamples, поставщик
+// отключается на circuitCooldown, затем пропускается один пробный вызов.
+const (
+	healthWindow      = 20
+	circuitMinSamples = 10
+	circuitErrorRate  = 0.5
+	circuitCooldown   = 30 * time.Second
+)
+
+type supplierHealth struct {
+	mu sync.Mutex
+
+	name        string
+	outcomes    []bool
+	next        int
+	lastSuccess time.Time
+	lastErrorAt time.Time
+	lastError   string
+
+	state    string
+	openedAt time.Time
+	trial    bool
+}
+
+func newSupplierHealth(name string) *supplierHealth {
+	return &supplierHealth{
+		name:     name,
+		outcomes: make([]bool, 0, healthWindow),
+		state:    CircuitClosed,
+	}
+}
+
+// allow решает, можно ли сейчас обращаться к поставщику.
+func (h *supplierHealth) allow() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	switch h.state {
+	case CircuitOpen:
+		if time.Since(h.openedAt) < circuitCooldown {
+			return false
+		}
+		h.state = CircuitHalfOpen
+		h.trial = true
+		return true
+	case CircuitHalfOpen:
+		// пока пробный вызов не завершился, остальные ждут
+		if h.trial {
+			return false
+		}
+		h.trial = true
+		return true
+	default:
+		return true
+	}
+}
+
+func (h *supplierHealth) record(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// отмена — решение вызывающей стороны, а не сбой поставщика: в
+	// статистику не идёт, но пробный вызов считается завершённым, иначе
+	// полуоткрытый автомат больше никого не пропустит
+	if errors.Is(err, context.Canceled) || errors.Is(err, ErrCircuitOpen) {
+		if h.state == CircuitHalfOpen {
+			h.trial = false
+		}
+		return
+	}
+
+	ok := err == nil
+	now := time.Now()
+
+	if ok {
+		h.lastSuccess = now
+	} else {
+		h.lastErrorAt = now
+		h.lastError = err.Error()
+	}
+
+	if len(h.outcomes) < healthWindow {
+		h.outcomes = append(h.outcomes, ok)
+	} else {
+		h.outcomes[h.next] = ok
+	}
+	h.next = (h.next + 1) % healthWindow
+
+	if h.state == CircuitHalfOpen {
+		h.trial = false
+		if ok {
+			h.state = CircuitClosed
+			h.outcomes = h.outcomes[:0]
+			h.next = 0
+		} else {
+			h.state = CircuitOpen
+			h.openedAt = now
+		}
+		return
+	}
+
+	if h.state == CircuitClosed && len(h.outcomes) >= circuitMinSamples && h.errorRate() >= circuitErrorRate {
+		h.state = CircuitOpen
+		h.openedAt = now
+	}
+}
+
+func (h *supplierHealth) errorRate() float64 {
+	if len(h.outcomes) == 0 {
+		return 0
+	}
+
+	failed := 0
+	for _, ok := range h.outcomes {
+		if !ok {
+			failed++
+		}
+	}
+
+	return float64(failed) / float64(len(h.outcomes))
+}
+
+func (h *supplierHealth) status() types.SupplierStatus {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	status := types.SupplierStatus{
+		Name:      h.name,
+		Samples:   len(h.outcomes),
+		ErrorRate: h.errorRate(),
+		Circuit:   h.state,
+		LastError: h.lastError,
+	}
+
+	if !h.lastSuccess.IsZero() {
+		t := h.lastSuccess.UTC()
+		status.LastSuccess = &t
+	}
+	if !h.lastErrorAt.IsZero() {
+		t := h.lastErrorAt.UTC()
+		status.LastErrorAt = &t
+	}
+
+	return status
+}
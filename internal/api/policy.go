@@ -0,0 +1,117 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrSupplierDisabled = errors.New("supplier is disabled")
+
+// Policy — настройки обращения к поставщику из секции конфига.
+type Policy struct {
+	Enabled       bool
+	Timeout       time.Duration // 0 — оставить таймаут клиента как есть
+	RetryAttempts int           // повторов после первой неудачи
+	RetryBackoff  time.Duration // пауза перед первым повтором, дальше удваивается
+	RateLimit     float64       // запросов в секунду, 0 — без ограничения
+}
+
+func DefaultPolicy() Policy {
+	return Policy{Enabled: true}
+}
+
+type supplierPolicy struct {
+	Policy
+	throttle *throttle
+}
+
+func newSupplierPolicy(p Policy) *supplierPolicy {
+	return &supplierPolicy{
+		Policy:   p,
+		throttle: newThrottle(p.RateLimit),
+	}
+}
+
+// retryable — ошибки, после которых повтор имеет смысл: сбой сети,
+// таймаут, 5xx и 429. Ошибки 4xx и разбора ответа не исправятся сами.
+func retryable(err error) bool {
+	switch ErrorClass(err) {
+	case "timeout", "network", "http_5xx", "rate_limited":
+		return true
+	}
+	return false
+}
+
+// do выполняет fn с ограничением частоты и повторами по политике.
+func (p *supplierPolicy) do(ctx context.Context, fn func(ctx context.Context) error) error {
+	backoff := p.RetryBackoff
+
+	for attempt := 0; ; attempt++ {
+		if err := p.throttle.wait(ctx); err != nil {
+			return err
+		}
+
+		err := fn(ctx)
+		if err == nil || attempt >= p.RetryAttempts || !retryable(err) || ctx.Err() != nil {
+			return err
+		}
+
+		if backoff > 0 {
+			timer := time.NewTimer(backoff)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return err
+			case <-timer.C:
+			}
+			backoff *= 2
+		}
+	}
+}
+
+// throttle равномерно распределяет запросы: каждый следующий получает
+// слот не раньше чем через interval после предыдущего.
+type throttle struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func newThrottle(rps float64) *throttle {
+	if rps <= 0 {
+		return nil
+	}
+	return &throttle{interval: time.Duration(float64(time.Second) / rps)}
+}
+
+func (t *throttle) wait(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	now := time.Now()
+	at := t.next
+	if at.Before(now) {
+		at = now
+	}
+	t.next = at.Add(t.interval)
+	t.mu.Unlock()
+
+	delay := time.Until(at)
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -4,9 +4,11 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"sync/atomic"
 	"time"
 
 	"dynamic-pricing-tool-ru/internal/logger"
@@ -16,19 +18,28 @@ import (
 )
 
 type PromelecClient struct {
-	httpClient *http.Client
+	httpClient atomic.Pointer[http.Client]
 	url        string
 	login      string
 	password   string
 }
 
-func NewPromelecClient(url, login, password string) *PromelecClient {
-	return &PromelecClient{
-		httpClient: &http.Client{Timeout: 15 * time.Second},
-		url:        "https://aaa.na4u.ru/rpc/",
-		login:      login,
-		password:   password,
+func NewPromelecClient(url, login, password string, timeout time.Duration) *PromelecClient {
+	c := &PromelecClient{
+		url:      url,
+		login:    login,
+		password: password,
 	}
+	c.SetTimeout(timeout)
+	return c
+}
+
+// SetTimeout заменяет HTTP-клиент; запросы в полёте дорабатывают со старым.
+func (c *PromelecClient) SetTimeout(timeout time.Duration) {
+	c.httpClient.Store(&http.Client{
+		Transport: transport(),
+		Timeout:   timeout,
+	})
 }
 
 type promelecRequest struct {
@@ -63,22 +74,37 @@ func (c *PromelecClient) SearchPart(ctx context.Context, partNumber string) (typ
 	req.Header.Set("Content-Type", "application/json")
 	req.ContentLength = int64(len(bodyBytes))
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.httpClient.Load().Do(req)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("request failed: %w", logger.RedactError(err))
 	}
 	defer resp.Body.Close()
 
-	raw, _ := io.ReadAll(resp.Body)
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read body error: %w", err)
+	}
 
-	logger.L.Info("PROMELEC RAW",
-		zap.ByteString("raw", raw),
+	logger.FromContext(ctx).Debug("PROMELEC RAW",
+		logger.BodyField(ctx, "raw", raw, false),
 	)
 
-	var result types.PromelecResponse
-	if err := json.Unmarshal(raw, &result); err != nil {
-		logger.L.Error("PROMELEC DECODE ERROR",
-			zap.ByteString("raw", raw),
+	// RPC-ошибка может прийти и с кодом, отличным от 200: сначала пробуем
+	// разобрать конверт, чтобы не потерять код и сообщение
+	result, err := decodePromelec(raw)
+
+	var rpcErr *PromelecError
+	if errors.As(err, &rpcErr) {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &StatusError{StatusCode: resp.StatusCode}
+	}
+
+	if err != nil {
+		logger.FromContext(ctx).Error("PROMELEC DECODE ERROR",
+			logger.BodyField(ctx, "raw", raw, true),
 			zap.Error(err),
 		)
 		return nil, fmt.Errorf("decode error: %w", err)
@@ -86,3 +112,78 @@ func (c *PromelecClient) SearchPart(ctx context.Context, partNumber string) (typ
 
 	return result, nil
 }
+
+// PromelecError — ошибка, которую вернул сам RPC Promelec (неверный
+// логин, неизвестный метод и т.п.), в отличие от сбоя транспорта.
+type PromelecError struct {
+	Code    int
+	Message string
+}
+
+func (e *PromelecError) Error() string {
+	if e.Code != 0 {
+		return fmt.Sprintf("promelec rpc error %d: %s", e.Code, e.Message)
+	}
+	return "promelec rpc error: " + e.Message
+}
+
+// promelecEnvelope — ответ-объект. Успешный ответ обычно голый массив,
+// но встречается и {"result": [...]}; ошибка — {"error": {...}} или
+// {"error": "текст", "code": N}.
+type promelecEnvelope struct {
+	Result  *types.PromelecResponse `json:"result"`
+	Error   json.RawMessage         `json:"error"`
+	Code    int                     `json:"code"`
+	Message string                  `json:"message"`
+}
+
+func decodePromelec(raw []byte) (types.PromelecResponse, error) {
+	trim := bytes.TrimSpace(raw)
+	if len(trim) == 0 {
+		return nil, fmt.Errorf("empty response")
+	}
+
+	if trim[0] == '[' {
+		var result types.PromelecResponse
+		if err := json.Unmarshal(trim, &result); err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+
+	var env promelecEnvelope
+	if err := json.Unmarshal(trim, &env); err != nil {
+		return nil, err
+	}
+
+	if len(env.Error) > 0 && string(env.Error) != "null" && string(env.Error) != "false" {
+		rpcErr := &PromelecError{Code: env.Code, Message: env.Message}
+
+		var text string
+		var obj struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		}
+
+		switch {
+		case json.Unmarshal(env.Error, &text) == nil:
+			rpcErr.Message = text
+		case json.Unmarshal(env.Error, &obj) == nil:
+			rpcErr.Code = obj.Code
+			rpcErr.Message = obj.Message
+		default:
+			rpcErr.Message = string(env.Error)
+		}
+
+		// сервер может повторить в сообщении пароль из запроса
+		rpcErr.Message = logger.CurrentRedactor().Text(rpcErr.Message)
+
+		return nil, rpcErr
+	}
+
+	if env.Result != nil {
+		return *env.Result, nil
+	}
+
+	return nil, fmt.Errorf("unexpected response object without result or error")
+}
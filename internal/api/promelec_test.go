@@ -0,0 +1,181 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"dynamic-pricing-tool-ru/internal/logger"
+)
+
+const (
+	promelecTestLogin    = "test-login"
+	promelecTestPassword = "test-password-8c1e"
+)
+
+func TestMain(m *testing.M) {
+	if err := logger.Init(logger.Options{
+		Level:       "error",
+		Output:      logger.OutputStdout,
+		BodyCapture: logger.BodyCaptureOff,
+	}); err != nil {
+		panic(err)
+	}
+	logger.SetRedactor(logger.NewRedactor(nil, []string{"login", "password"}, []string{promelecTestPassword}))
+
+	m.Run()
+}
+
+func TestPromelecSearchPart(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+
+		wantItems  []string
+		wantRPC    *PromelecError
+		wantStatus int
+		wantErr    bool
+	}{
+		{
+			name:      "bare array",
+			status:    http.StatusOK,
+			body:      `[{"item_id": 310452, "name": "NE555DR", "quant": 730, "pricebreaks": [{"quant": 1, "price": 38.5}]}]`,
+			wantItems: []string{"NE555DR"},
+		},
+		{
+			name:      "empty array",
+			status:    http.StatusOK,
+			body:      `[]`,
+			wantItems: []string{},
+		},
+		{
+			name:      "result envelope",
+			status:    http.StatusOK,
+			body:      `{"result": [{"item_id": 1, "name": "LM317DCYR"}, {"item_id": 2, "name": "LM317DCYRG3"}]}`,
+			wantItems: []string{"LM317DCYR", "LM317DCYRG3"},
+		},
+		{
+			name:    "error string",
+			status:  http.StatusOK,
+			body:    `{"error": "Неверный логин или пароль"}`,
+			wantRPC: &PromelecError{Message: "Неверный логин или пароль"},
+		},
+		{
+			name:    "error string with top-level code",
+			status:  http.StatusOK,
+			body:    `{"error": "unknown method", "code": 404}`,
+			wantRPC: &PromelecError{Code: 404, Message: "unknown method"},
+		},
+		{
+			name:    "error object",
+			status:  http.StatusOK,
+			body:    `{"error": {"code": 401, "message": "auth failed"}, "code": 401}`,
+			wantRPC: &PromelecError{Code: 401, Message: "auth failed"},
+		},
+		{
+			name:    "error echoes password",
+			status:  http.StatusOK,
+			body:    `{"error": "bad password ` + promelecTestPassword + `"}`,
+			wantRPC: &PromelecError{Message: "bad password [REDACTED]"},
+		},
+		{
+			// RPC-ошибка важнее кода ответа: код и сообщение не теряются
+			name:    "error object with non-200 status",
+			status:  http.StatusUnauthorized,
+			body:    `{"error": {"code": 401, "message": "auth failed"}}`,
+			wantRPC: &PromelecError{Code: 401, Message: "auth failed"},
+		},
+		{
+			name:       "non-200 with html",
+			status:     http.StatusBadGateway,
+			body:       `<html><body>502 Bad Gateway</body></html>`,
+			wantStatus: http.StatusBadGateway,
+		},
+		{
+			name:       "non-200 with array",
+			status:     http.StatusServiceUnavailable,
+			body:       `[]`,
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:    "object without result or error",
+			status:  http.StatusOK,
+			body:    `{"status": "ok"}`,
+			wantErr: true,
+		},
+		{
+			name:    "empty body",
+			status:  http.StatusOK,
+			body:    ``,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var req promelecRequest
+				raw, _ := io.ReadAll(r.Body)
+				if err := json.Unmarshal(raw, &req); err != nil {
+					t.Errorf("request body is not JSON: %s", raw)
+				}
+				if r.Method != http.MethodPost || req.Method != "items_data_find" || req.Name != "NE555DR" ||
+					req.Login != promelecTestLogin || req.Password != promelecTestPassword {
+					t.Errorf("unexpected request %s %+v", r.Method, req)
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				io.WriteString(w, tt.body)
+			}))
+			defer srv.Close()
+
+			client := NewPromelecClient(srv.URL, promelecTestLogin, promelecTestPassword, 5*time.Second)
+			items, err := client.SearchPart(context.Background(), "NE555DR")
+
+			switch {
+			case tt.wantRPC != nil:
+				var rpcErr *PromelecError
+				if !errors.As(err, &rpcErr) {
+					t.Fatalf("err = %v, want PromelecError", err)
+				}
+				if *rpcErr != *tt.wantRPC {
+					t.Errorf("rpc error = %+v, want %+v", *rpcErr, *tt.wantRPC)
+				}
+				if strings.Contains(err.Error(), promelecTestPassword) {
+					t.Errorf("error leaks password: %v", err)
+				}
+
+			case tt.wantStatus != 0:
+				var statusErr *StatusError
+				if !errors.As(err, &statusErr) || statusErr.StatusCode != tt.wantStatus {
+					t.Fatalf("err = %v, want status %d", err, tt.wantStatus)
+				}
+
+			case tt.wantErr:
+				if err == nil {
+					t.Fatalf("expected decode error, got %d items", len(items))
+				}
+
+			default:
+				if err != nil {
+					t.Fatal(err)
+				}
+				names := []string{}
+				for _, item := range items {
+					names = append(names, item.Name)
+				}
+				if strings.Join(names, ",") != strings.Join(tt.wantItems, ",") {
+					t.Errorf("items = %v, want %v", names, tt.wantItems)
+				}
+			}
+		})
+	}
+}
@@ -1,55 +1,241 @@
 package config
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
 	"os"
-	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
+// DefaultPath — файл конфигурации, который читается, если CONFIG_FILE не задан.
+// Его отсутствие не ошибка: тогда работают значения по умолчанию и env.
+const DefaultPath = "config.yaml"
+
 type Config struct {
-	ServerPort     string
-	GetchipsURL    string
-	GetchipsToken  string
-	EfindURL       string
-	EfindToken     string
-	PromelecURL    string
-	PromelecLogin  string
-	PromelecPass   string
-	RedisAddr      string
-	RabbitMQURL    string
-	ChunkSize      int
-	WorkerPoolSize int
-}
-
-func LoadConfig() Config {
-	cfg := Config{
-		ServerPort:     getEnv("PORT", "5004"),
-		GetchipsURL:    getEnv("GETCHIPS_URL", "https://api.client-service.getchips.ru/client/api/gh/v1/search/partnumber"),
-		GetchipsToken:  getEnv("GETCHIPS_TOKEN", ""),
-		EfindURL:       getEnv("EFIND_URL", "https://efind.ru/api/search"),
-		EfindToken:     getEnv("EFIND_TOKEN", ""),
-		PromelecURL:    getEnv("PROMELEC_URL", "https://aaa.na4u.ru/rpc"),
-		PromelecLogin:  getEnv("PROMELEC_LOGIN", ""),
-		PromelecPass:   getEnv("PROMELEC_PASS", ""),
-		RedisAddr:      getEnv("REDIS_ADDR", ""),
-		RabbitMQURL:    getEnv("RABBITMQ_URL", ""),
-		ChunkSize:      getEnvAsInt("CHUNK_SIZE", 50),
-		WorkerPoolSize: getEnvAsInt("WORKER_POOL_SIZE", 20),
-	}
-
-	return cfg
-}
-
-func getEnv(key, defaultValue string) string {
-	if value, exists := os.LookupEnv(key); exists {
-		return value
-	}
-	return defaultValue
-}
-
-func getEnvAsInt(key string, defaultValue int) int {
-	valueStr := getEnv(key, "")
-	if value, err := strconv.Atoi(valueStr); err == nil {
-		return value
-	}
-	return defaultValue
+	ServerPort     string          `yaml:"port"`
+	Suppliers      SuppliersConfig `yaml:"suppliers"`
+	RedisAddr      string          `yaml:"redis_addr"`
+	RabbitMQURL    string          `yaml:"rabbitmq_url"`
+	ChunkSize      int             `yaml:"chunk_size"`
+	WorkerPoolSize int             `yaml:"worker_pool_size"`
+	DatabasePath   string          `yaml:"database_path"`
+	HistoryBuffer  int             `yaml:"history_buffer"`
+	WatchInterval  time.Duration   `yaml:"watch_interval"`
+	WebhookURL     string          `yaml:"watch_webhook_url"`
+	WebhookSecret  string          `yaml:"watch_webhook_secret"`
+	QuoteValidDays int             `yaml:"quote_valid_days"`
+	VATRate        float64         `yaml:"vat_rate"`
+	VATIncluded    []string        `yaml:"prices_with_vat"`
+	USDRate        float64         `yaml:"usd_rub_rate"`
+	MarkupCoef     float64         `yaml:"markup_coef"`
+	TracesExporter string          `yaml:"traces_exporter"`
+	ServiceName    string          `yaml:"service_name"`
+
+	RedactQueryParams []string `yaml:"log_redact_query_params"`
+	RedactBodyFields  []string `yaml:"log_redact_body_fields"`
+
+	LogLevel            string  `yaml:"log_level"`
+	LogOutput           string  `yaml:"log_output"`
+	LogDir              string  `yaml:"log_dir"`
+	LogBodyCapture      string  `yaml:"log_body_capture"`
+	LogBodySampleRate   float64 `yaml:"log_body_sample_rate"`
+	LogMaxBodySize      int     `yaml:"log_max_body_size"`
+	LogAllowDebugHeader bool    `yaml:"log_allow_debug_header"`
+
+	ShutdownDrainTimeout   time.Duration `yaml:"shutdown_drain_timeout"`
+	ShutdownReadinessDelay time.Duration `yaml:"shutdown_readiness_delay"`
+
+	HealthCanaryMPN string `yaml:"health_canary_mpn"`
+
+	AuthEnabled bool   `yaml:"auth_enabled"`
+	AdminAPIKey string `yaml:"admin_api_key"`
+
+	MaxBOMRows        int    `yaml:"max_bom_rows"`
+	MaxBodyBytes      int64  `yaml:"max_body_bytes"`
+	MaxMPNLength      int    `yaml:"max_mpn_length"`
+	MPNAllowedPattern string `yaml:"mpn_allowed_pattern"`
+
+	// штук в катушке и лотке для количеств вида "5 reels"
+	ReelSize int `yaml:"qty_reel_size"`
+	TraySize int `yaml:"qty_tray_size"`
+
+	// как часто проверять файл конфигурации на изменения; 0 — не проверять
+	ConfigWatchInterval time.Duration `yaml:"config_watch_interval"`
+
+	// запись или воспроизведение обмена с поставщиками: off, record, replay
+	SupplierFixtures    string `yaml:"supplier_fixtures"`
+	SupplierFixturesDir string `yaml:"supplier_fixtures_dir"`
+}
+
+type SuppliersConfig struct {
+	Getchips SupplierConfig `yaml:"getchips"`
+	Efind    SupplierConfig `yaml:"efind"`
+	Promelec SupplierConfig `yaml:"promelec"`
+}
+
+// SupplierConfig — секция поставщика. Token используют Getchips и Efind,
+// Login/Password — Promelec.
+type SupplierConfig struct {
+	Enabled   bool          `yaml:"enabled"`
+	URL       string        `yaml:"url"`
+	Token     string        `yaml:"token"`
+	Login     string        `yaml:"login"`
+	Password  string        `yaml:"password"`
+	Timeout   time.Duration `yaml:"timeout"`
+	Retry     RetryConfig   `yaml:"retry"`
+	RateLimit float64       `yaml:"rate_limit"` // запросов в секунду, 0 — без ограничения
+}
+
+type RetryConfig struct {
+	Attempts int           `yaml:"attempts"` // повторов после первой неудачи
+	Backoff  time.Duration `yaml:"backoff"`  // пауза перед первым повтором, дальше удваивается
+}
+
+// Problems возвращает замечания к конфигурации, не мешающие запуску:
+// например, без учётных данных Promelec каждый запрос к нему будет ошибкой.
+func (c Config) Problems() []string {
+	var problems []string
+
+	promelec := c.Suppliers.Promelec
+	if promelec.Enabled && (promelec.Login == "" || promelec.Password == "") {
+		problems = append(problems, "PROMELEC_LOGIN/PROMELEC_PASS are not set")
+	}
+	if c.WatchInterval > 0 && c.WebhookURL != "" && c.WebhookSecret == "" {
+		problems = append(problems, "WATCH_WEBHOOK_SECRET is not set, webhook payloads are signed with an empty key")
+	}
+	if !c.AuthEnabled && c.AdminAPIKey == "" {
+		problems = append(problems, "AUTH_ENABLED is false and ADMIN_API_KEY is not set, API and admin endpoints are open to anyone who can reach the port")
+	} else if !c.AuthEnabled {
+		problems = append(problems, "AUTH_ENABLED is false, API is open to anyone who can reach the port")
+	} else if c.AdminAPIKey == "" {
+		problems = append(problems, "ADMIN_API_KEY is not set, admin endpoints are unreachable")
+	}
+	if c.USDRate <= 0 {
+		problems = append(problems, "USD_RUB_RATE is not set, customer currency conversion is disabled")
+	}
+
+	return problems
+}
+
+// Secrets — значения, которые нельзя выводить в логи.
+func (c Config) Secrets() []string {
+	s := c.Suppliers
+	return []string{
+		s.Getchips.Token, s.Efind.Token, s.Promelec.Password,
+		c.WebhookSecret, c.AdminAPIKey,
+	}
+}
+
+func Default() Config {
+	return Config{
+		ServerPort: "5004",
+		Suppliers: SuppliersConfig{
+			Getchips: SupplierConfig{
+				Enabled: true,
+				URL:     "https://api.client-service.getchips.ru/client/api/gh/v1/search/partnumber",
+				Timeout: 30 * time.Second,
+			},
+			Efind: SupplierConfig{
+				Enabled: true,
+				URL:     "https://efind.ru/api/search",
+				Timeout: 30 * time.Second,
+			},
+			Promelec: SupplierConfig{
+				Enabled: true,
+				URL:     "https://aaa.na4u.ru/rpc/",
+				Timeout: 15 * time.Second,
+			},
+		},
+		ChunkSize:      50,
+		WorkerPoolSize: 20,
+		DatabasePath:   "data/pricing.db",
+		HistoryBuffer:  1000,
+		WatchInterval:  time.Hour,
+		QuoteValidDays: 5,
+		VATRate:        20,
+		VATIncluded:    []string{"promelec"},
+		MarkupCoef:     1.10,
+		TracesExporter: "none",
+		ServiceName:    "dynamic-pricing-tool-ru",
+
+		RedactQueryParams: []string{"token", "access_token", "api_key", "apikey", "password"},
+		RedactBodyFields:  []string{"login", "password"},
+
+		LogLevel:            "debug",
+		LogOutput:           "file",
+		LogDir:              "logs",
+		LogBodyCapture:      "full",
+		LogBodySampleRate:   0.1,
+		LogMaxBodySize:      4096,
+		LogAllowDebugHeader: false,
+
+		ShutdownDrainTimeout:   60 * time.Second,
+		ShutdownReadinessDelay: 5 * time.Second,
+
+		MaxBOMRows:        5000,
+		MaxBodyBytes:      10 << 20,
+		MaxMPNLength:      64,
+		MPNAllowedPattern: `^[\p{L}\p{N} .,:;/\\#+\-_()\[\]*%]+$`,
+		ReelSize:          3000,
+		TraySize:          100,
+
+		ConfigWatchInterval: 5 * time.Second,
+
+		SupplierFixtures:    "off",
+		SupplierFixturesDir: "testdata/fixtures",
+	}
+}
+
+// Load собирает конфигурацию: значения по умолчанию, затем YAML-файл,
+// затем переменные окружения. Путь к файлу берётся из CONFIG_FILE; если
+// переменная не задана, читается config.yaml, при его отсутствии — только env.
+// Все найденные ошибки возвращаются вместе, а не по одной.
+func Load() (Config, error) {
+	cfg := Default()
+
+	var errs []error
+
+	path, explicit := Path()
+	if path != "" {
+		if err := loadFile(path, &cfg); err != nil {
+			if explicit || !errors.Is(err, os.ErrNotExist) {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	errs = append(errs, applyEnv(&cfg)...)
+	errs = append(errs, cfg.Validate()...)
+
+	return cfg, errors.Join(errs...)
+}
+
+// Path возвращает путь к файлу конфигурации и признак того, что он задан
+// явно через CONFIG_FILE.
+func Path() (string, bool) {
+	if path, ok := os.LookupEnv("CONFIG_FILE"); ok {
+		return path, true
+	}
+	return DefaultPath, false
+}
+
+func loadFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	// опечатка в имени ключа молча превратилась бы в значение по умолчанию
+	dec.KnownFields(true)
+
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("parse config file %s: %w", path, err)
+	}
+
+	return nil
 }
@@ -0,0 +1,177 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// envReader переопределяет значения из файла переменными окружения и
+// копит ошибки разбора: неверное число не должно тихо превращаться в
+// значение по умолчанию.
+type envReader struct {
+	errs []error
+}
+
+func (r *envReader) lookup(key string) (string, bool) {
+	value, ok := os.LookupEnv(key)
+	return strings.TrimSpace(value), ok
+}
+
+func (r *envReader) fail(key, value string, err error) {
+	r.errs = append(r.errs, fmt.Errorf("%s=%q: %w", key, value, err))
+}
+
+func (r *envReader) String(key string, dst *string) {
+	if value, ok := os.LookupEnv(key); ok {
+		*dst = value
+	}
+}
+
+func (r *envReader) Int(key string, dst *int) {
+	if value, ok := r.lookup(key); ok && value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			r.fail(key, value, fmt.Errorf("not an integer"))
+			return
+		}
+		*dst = n
+	}
+}
+
+func (r *envReader) Int64(key string, dst *int64) {
+	if value, ok := r.lookup(key); ok && value != "" {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			r.fail(key, value, fmt.Errorf("not an integer"))
+			return
+		}
+		*dst = n
+	}
+}
+
+func (r *envReader) Float(key string, dst *float64) {
+	if value, ok := r.lookup(key); ok && value != "" {
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			r.fail(key, value, fmt.Errorf("not a number"))
+			return
+		}
+		*dst = f
+	}
+}
+
+func (r *envReader) Bool(key string, dst *bool) {
+	if value, ok := r.lookup(key); ok && value != "" {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			r.fail(key, value, fmt.Errorf("not a boolean"))
+			return
+		}
+		*dst = b
+	}
+}
+
+func (r *envReader) Duration(key string, dst *time.Duration) {
+	if value, ok := r.lookup(key); ok && value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			r.fail(key, value, fmt.Errorf("not a duration"))
+			return
+		}
+		*dst = d
+	}
+}
+
+// List читает список через запятую, пустые элементы отбрасываются.
+func (r *envReader) List(key string, dst *[]string) {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return
+	}
+
+	var result []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	*dst = result
+}
+
+// supplier читает секцию поставщика из переменных с префиксом,
+// например GETCHIPS_URL, GETCHIPS_TIMEOUT, GETCHIPS_RETRY_ATTEMPTS.
+func (r *envReader) supplier(prefix string, s *SupplierConfig) {
+	r.Bool(prefix+"_ENABLED", &s.Enabled)
+	r.String(prefix+"_URL", &s.URL)
+	r.Duration(prefix+"_TIMEOUT", &s.Timeout)
+	r.Int(prefix+"_RETRY_ATTEMPTS", &s.Retry.Attempts)
+	r.Duration(prefix+"_RETRY_BACKOFF", &s.Retry.Backoff)
+	r.Float(prefix+"_RATE_LIMIT", &s.RateLimit)
+}
+
+func applyEnv(cfg *Config) []error {
+	r := &envReader{}
+
+	r.String("PORT", &cfg.ServerPort)
+
+	r.supplier("GETCHIPS", &cfg.Suppliers.Getchips)
+	r.String("GETCHIPS_TOKEN", &cfg.Suppliers.Getchips.Token)
+	r.supplier("EFIND", &cfg.Suppliers.Efind)
+	r.String("EFIND_TOKEN", &cfg.Suppliers.Efind.Token)
+	r.supplier("PROMELEC", &cfg.Suppliers.Promelec)
+	r.String("PROMELEC_LOGIN", &cfg.Suppliers.Promelec.Login)
+	r.String("PROMELEC_PASS", &cfg.Suppliers.Promelec.Password)
+
+	r.String("REDIS_ADDR", &cfg.RedisAddr)
+	r.String("RABBITMQ_URL", &cfg.RabbitMQURL)
+	r.Int("CHUNK_SIZE", &cfg.ChunkSize)
+	r.Int("WORKER_POOL_SIZE", &cfg.WorkerPoolSize)
+	r.String("DATABASE_PATH", &cfg.DatabasePath)
+	r.Int("HISTORY_BUFFER", &cfg.HistoryBuffer)
+	r.Duration("WATCH_INTERVAL", &cfg.WatchInterval)
+	r.String("WATCH_WEBHOOK_URL", &cfg.WebhookURL)
+	r.String("WATCH_WEBHOOK_SECRET", &cfg.WebhookSecret)
+	r.Int("QUOTE_VALID_DAYS", &cfg.QuoteValidDays)
+	r.Float("VAT_RATE", &cfg.VATRate)
+	r.List("PRICES_WITH_VAT", &cfg.VATIncluded)
+	r.Float("USD_RUB_RATE", &cfg.USDRate)
+	r.Float("MARKUP_COEF", &cfg.MarkupCoef)
+	r.String("OTEL_TRACES_EXPORTER", &cfg.TracesExporter)
+	r.String("OTEL_SERVICE_NAME", &cfg.ServiceName)
+
+	r.List("LOG_REDACT_QUERY_PARAMS", &cfg.RedactQueryParams)
+	r.List("LOG_REDACT_BODY_FIELDS", &cfg.RedactBodyFields)
+
+	r.String("LOG_LEVEL", &cfg.LogLevel)
+	r.String("LOG_OUTPUT", &cfg.LogOutput)
+	r.String("LOG_DIR", &cfg.LogDir)
+	r.String("LOG_BODY_CAPTURE", &cfg.LogBodyCapture)
+	r.Float("LOG_BODY_SAMPLE_RATE", &cfg.LogBodySampleRate)
+	r.Int("LOG_MAX_BODY_SIZE", &cfg.LogMaxBodySize)
+	r.Bool("LOG_ALLOW_DEBUG_HEADER", &cfg.LogAllowDebugHeader)
+
+	r.Duration("SHUTDOWN_DRAIN_TIMEOUT", &cfg.ShutdownDrainTimeout)
+	r.Duration("SHUTDOWN_READINESS_DELAY", &cfg.ShutdownReadinessDelay)
+
+	r.String("HEALTH_CANARY_MPN", &cfg.HealthCanaryMPN)
+
+	r.Bool("AUTH_ENABLED", &cfg.AuthEnabled)
+	r.String("ADMIN_API_KEY", &cfg.AdminAPIKey)
+
+	r.Int("MAX_BOM_ROWS", &cfg.MaxBOMRows)
+	r.Int64("MAX_BODY_BYTES", &cfg.MaxBodyBytes)
+	r.Int("MAX_MPN_LENGTH", &cfg.MaxMPNLength)
+	r.String("MPN_ALLOWED_PATTERN", &cfg.MPNAllowedPattern)
+	r.Int("QTY_REEL_SIZE", &cfg.ReelSize)
+	r.Int("QTY_TRAY_SIZE", &cfg.TraySize)
+
+	r.Duration("CONFIG_WATCH_INTERVAL", &cfg.ConfigWatchInterval)
+
+	r.String("SUPPLIER_FIXTURES", &cfg.SupplierFixtures)
+	r.String("SUPPLIER_FIXTURES_DIR", &cfg.SupplierFixturesDir)
+
+	return r.errs
+}
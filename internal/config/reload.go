@@ -0,0 +1,198 @@
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"dynamic-pricing-tool-ru/internal/logger"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// Version — короткий хеш изменяемой на ходу части конфигурации. По нему
+// видно, какая версия применена: он отдаётся в заголовке ответов и в
+// /health. Остальные поля, включая токены и ключи, в хеш не входят, иначе
+// по заголовку можно было бы подбирать секреты.
+func (c Config) Version() string {
+	var reloadable Config
+	applyReloadable(&reloadable, c)
+
+	data, _ := yaml.Marshal(reloadable)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:6])
+}
+
+// applyReloadable переносит в dst поля, которые можно менять на ходу:
+// цены и наценку, уровень логов и настройки обращения к поставщикам.
+func applyReloadable(dst *Config, src Config) {
+	dst.VATRate = src.VATRate
+	dst.VATIncluded = src.VATIncluded
+	dst.USDRate = src.USDRate
+	dst.MarkupCoef = src.MarkupCoef
+	dst.LogLevel = src.LogLevel
+
+	for _, pair := range []struct {
+		dst *SupplierConfig
+		src SupplierConfig
+	}{
+		{&dst.Suppliers.Getchips, src.Suppliers.Getchips},
+		{&dst.Suppliers.Efind, src.Suppliers.Efind},
+		{&dst.Suppliers.Promelec, src.Suppliers.Promelec},
+	} {
+		pair.dst.Enabled = pair.src.Enabled
+		pair.dst.Timeout = pair.src.Timeout
+		pair.dst.Retry = pair.src.Retry
+		pair.dst.RateLimit = pair.src.RateLimit
+	}
+}
+
+// RestartRequired возвращает ключи, изменения которых вступят в силу
+// только после перезапуска.
+func RestartRequired(active, loaded Config) []string {
+	merged := active
+	applyReloadable(&merged, loaded)
+
+	a, b := asMap(merged), asMap(loaded)
+
+	var keys []string
+	for key := range b {
+		if !reflect.DeepEqual(a[key], b[key]) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func asMap(c Config) map[string]any {
+	data, _ := yaml.Marshal(c)
+	m := map[string]any{}
+	_ = yaml.Unmarshal(data, &m)
+	return m
+}
+
+type ReloadResult struct {
+	Version         string   `json:"version"`
+	Changed         bool     `json:"changed"`
+	RestartRequired []string `json:"restart_required,omitempty"`
+}
+
+// Reloader перечитывает конфигурацию и применяет изменяемую на ходу часть.
+// Новая конфигурация сначала целиком проверяется; при ошибке продолжает
+// действовать прежняя.
+type Reloader struct {
+	mu      sync.Mutex
+	apply   func(Config)
+	current atomic.Pointer[Config]
+}
+
+// NewReloader запоминает уже применённую при запуске конфигурацию. apply
+// вызывается при каждом изменении и должен подменять настройки атомарно.
+func NewReloader(cfg Config, apply func(Config)) *Reloader {
+	r := &Reloader{apply: apply}
+	r.current.Store(&cfg)
+	return r
+}
+
+func (r *Reloader) Current() Config {
+	return *r.current.Load()
+}
+
+func (r *Reloader) Version() string {
+	return r.Current().Version()
+}
+
+func (r *Reloader) Reload() (ReloadResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	active := r.Current()
+
+	loaded, err := Load()
+	if err != nil {
+		return ReloadResult{Version: active.Version()}, err
+	}
+
+	next := active
+	applyReloadable(&next, loaded)
+
+	result := ReloadResult{
+		Version:         next.Version(),
+		Changed:         next.Version() != active.Version(),
+		RestartRequired: RestartRequired(active, loaded),
+	}
+
+	if result.Changed {
+		r.apply(next)
+		r.current.Store(&next)
+
+		logger.L.Info("Config reloaded",
+			zap.String("from", active.Version()),
+			zap.String("to", result.Version))
+	}
+
+	if len(result.RestartRequired) > 0 {
+		logger.L.Warn("Config changes require restart",
+			zap.Strings("keys", result.RestartRequired))
+	}
+
+	return result, nil
+}
+
+// Watch проверяет файл конфигурации раз в interval и перезагружает его
+// при изменении. Сравнивается время изменения и размер: редакторы часто
+// заменяют файл целиком, и события файловой системы теряются.
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration) {
+	path, _ := Path()
+	if path == "" || interval <= 0 {
+		return
+	}
+
+	stamp := func() (time.Time, int64) {
+		info, err := os.Stat(path)
+		if err != nil {
+			return time.Time{}, -1
+		}
+		return info.ModTime(), info.Size()
+	}
+
+	modTime, size := stamp()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		m, s := stamp()
+		if m.Equal(modTime) && s == size {
+			continue
+		}
+		modTime, size = m, s
+
+		if s < 0 {
+			logger.L.Warn("Config file disappeared, keeping current config",
+				zap.String("path", path))
+			continue
+		}
+
+		if _, err := r.Reload(); err != nil {
+			logger.L.Error("Config reload failed, keeping current config",
+				zap.String("path", path),
+				zap.Error(err))
+		}
+	}
+}
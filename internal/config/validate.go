@@ -0,0 +1,162 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+)
+
+// Validate проверяет конфигурацию целиком и возвращает все ошибки сразу,
+// чтобы при запуске не приходилось исправлять их по одной.
+func (c Config) Validate() []error {
+	var errs []error
+
+	fail := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if port, err := strconv.Atoi(c.ServerPort); err != nil || port <= 0 || port > 65535 {
+		fail("port: %q is not a valid TCP port", c.ServerPort)
+	}
+
+	enabled := 0
+	for _, s := range []struct {
+		name string
+		cfg  SupplierConfig
+	}{
+		{"getchips", c.Suppliers.Getchips},
+		{"efind", c.Suppliers.Efind},
+		{"promelec", c.Suppliers.Promelec},
+	} {
+		if !s.cfg.Enabled {
+			continue
+		}
+		enabled++
+
+		if u, err := url.Parse(s.cfg.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			fail("suppliers.%s.url: %q is not an http(s) URL", s.name, s.cfg.URL)
+		}
+		if s.cfg.Timeout <= 0 {
+			fail("suppliers.%s.timeout: must be positive", s.name)
+		}
+		if s.cfg.Retry.Attempts < 0 {
+			fail("suppliers.%s.retry.attempts: must not be negative", s.name)
+		}
+		if s.cfg.Retry.Backoff < 0 {
+			fail("suppliers.%s.retry.backoff: must not be negative", s.name)
+		}
+		if s.cfg.RateLimit < 0 {
+			fail("suppliers.%s.rate_limit: must not be negative", s.name)
+		}
+	}
+
+	// при воспроизведении фикстур поставщики не вызываются, а токены в
+	// ключах фикстур замаскированы, поэтому настоящие не нужны
+	if c.SupplierFixtures != "replay" {
+		if c.Suppliers.Getchips.Enabled && c.Suppliers.Getchips.Token == "" {
+			fail("suppliers.getchips.token (GETCHIPS_TOKEN) is required")
+		}
+		if c.Suppliers.Efind.Enabled && c.Suppliers.Efind.Token == "" {
+			fail("suppliers.efind.token (EFIND_TOKEN) is required")
+		}
+	}
+	if enabled == 0 {
+		fail("suppliers: at least one supplier must be enabled")
+	}
+
+	if c.ChunkSize <= 0 {
+		fail("chunk_size: must be positive")
+	}
+	if c.WorkerPoolSize <= 0 {
+		fail("worker_pool_size: must be positive")
+	}
+	if c.HistoryBuffer < 0 {
+		fail("history_buffer: must not be negative")
+	}
+	if c.WatchInterval < 0 {
+		fail("watch_interval: must not be negative")
+	}
+	if c.QuoteValidDays <= 0 {
+		fail("quote_valid_days: must be positive")
+	}
+	if c.VATRate < 0 || c.VATRate > 100 {
+		fail("vat_rate: %v is not a percentage", c.VATRate)
+	}
+	if c.USDRate < 0 {
+		fail("usd_rub_rate: must not be negative")
+	} else if c.USDRate == 0 && (c.Suppliers.Getchips.Enabled || c.Suppliers.Efind.Enabled) {
+		// без курса цены в долларах не сравнить с рублёвыми: такие офферы
+		// выпали бы из сводок, сортировки и анализа
+		fail("usd_rub_rate (USD_RUB_RATE) is required when getchips or efind is enabled, they quote prices in USD")
+	}
+	if c.MarkupCoef <= 0 {
+		fail("markup_coef: must be positive")
+	}
+
+	switch c.TracesExporter {
+	case "", "none", "otlp", "console", "stdout":
+	default:
+		fail("traces_exporter: unknown exporter %q", c.TracesExporter)
+	}
+
+	switch c.LogLevel {
+	case "debug", "info", "warn", "error", "dpanic", "panic", "fatal":
+	default:
+		fail("log_level: unknown level %q", c.LogLevel)
+	}
+	switch c.LogOutput {
+	case "stdout", "file", "both":
+	default:
+		fail("log_output: must be stdout, file or both, got %q", c.LogOutput)
+	}
+	switch c.LogBodyCapture {
+	case "off", "errors", "sampled", "full":
+	default:
+		fail("log_body_capture: must be off, errors, sampled or full, got %q", c.LogBodyCapture)
+	}
+	if c.LogBodySampleRate < 0 || c.LogBodySampleRate > 1 {
+		fail("log_body_sample_rate: must be between 0 and 1")
+	}
+	if c.LogMaxBodySize < 0 {
+		fail("log_max_body_size: must not be negative")
+	}
+
+	if c.ShutdownDrainTimeout < 0 || c.ShutdownReadinessDelay < 0 {
+		fail("shutdown timeouts must not be negative")
+	}
+
+	if c.MaxBOMRows < 0 {
+		fail("max_bom_rows: must not be negative")
+	}
+	if c.MaxBodyBytes <= 0 {
+		fail("max_body_bytes: must be positive")
+	}
+	if c.MaxMPNLength < 0 {
+		fail("max_mpn_length: must not be negative")
+	}
+	if c.MPNAllowedPattern != "" {
+		if _, err := regexp.Compile(c.MPNAllowedPattern); err != nil {
+			fail("mpn_allowed_pattern: %v", err)
+		}
+	}
+	if c.ReelSize < 0 || c.TraySize < 0 {
+		fail("qty_reel_size/qty_tray_size: must not be negative")
+	}
+
+	if c.ConfigWatchInterval < 0 {
+		fail("config_watch_interval: must not be negative")
+	}
+
+	switch c.SupplierFixtures {
+	case "", "off":
+	case "record", "replay":
+		if c.SupplierFixturesDir == "" {
+			fail("supplier_fixtures_dir: required when supplier_fixtures is %s", c.SupplierFixtures)
+		}
+	default:
+		fail("supplier_fixtures: must be off, record or replay, got %q", c.SupplierFixtures)
+	}
+
+	return errs
+}
@@ -0,0 +1,288 @@
+// Package fixtures записывает обмен с поставщиками в файлы и воспроизводит
+// его без сети: для локальной разработки и прогона /process в CI.
+package fixtures
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"dynamic-pricing-tool-ru/internal/logger"
+
+	"go.uber.org/zap"
+)
+
+const (
+	ModeOff    = "off"
+	ModeRecord = "record"
+	ModeReplay = "replay"
+)
+
+// Fixture — одна пара запрос/ответ. Тела в формате JSON хранятся как есть,
+// чтобы файлы было удобно читать и править руками; остальные — строкой.
+type Fixture struct {
+	Request  Message `json:"request"`
+	Response Message `json:"response"`
+}
+
+type Message struct {
+	Method      string          `json:"method,omitempty"`
+	URL         string          `json:"url,omitempty"`
+	Status      int             `json:"status,omitempty"`
+	ContentType string          `json:"content_type,omitempty"`
+	Body        json.RawMessage `json:"body,omitempty"`
+	BodyText    string          `json:"body_text,omitempty"`
+}
+
+func setBody(m *Message, body []byte) {
+	if len(body) == 0 {
+		return
+	}
+	if json.Valid(body) {
+		var buf bytes.Buffer
+		if json.Indent(&buf, body, "", "  ") == nil {
+			m.Body = buf.Bytes()
+			return
+		}
+	}
+	m.BodyText = string(body)
+}
+
+func (m Message) body() []byte {
+	if len(m.Body) > 0 {
+		return m.Body
+	}
+	return []byte(m.BodyText)
+}
+
+// Transport возвращает транспорт для режима: запись поверх next,
+// воспроизведение из dir или next как есть.
+func Transport(mode, dir string, next http.RoundTripper) (http.RoundTripper, error) {
+	switch mode {
+	case "", ModeOff:
+		return next, nil
+	case ModeRecord:
+		return NewRecorder(dir, next), nil
+	case ModeReplay:
+		return NewReplayer(dir), nil
+	default:
+		return nil, fmt.Errorf("unknown fixtures mode %q", mode)
+	}
+}
+
+// key — имя файла фикстуры. Строится по запросу с уже замаскированными
+// секретами, поэтому запись с боевыми токенами и воспроизведение с
+// тестовыми дают один и тот же ключ.
+func key(method string, u *url.URL, body []byte) (dir, name string) {
+	redactor := logger.CurrentRedactor()
+
+	safeURL := redactor.URL(u)
+	if parsed, err := url.Parse(safeURL); err == nil {
+		parsed.RawQuery = parsed.Query().Encode()
+		safeURL = parsed.String()
+	}
+
+	safeBody := normalizeJSON(redactor.Body(body))
+
+	sum := sha256.Sum256([]byte(method + " " + safeURL + "\n" + string(safeBody)))
+
+	return sanitize(u.Host), fmt.Sprintf("%s_%s_%s.json", method, sanitize(lastSegment(u.Path)), hex.EncodeToString(sum[:6]))
+}
+
+// normalizeJSON приводит JSON к виду с отсортированными ключами, чтобы
+// порядок полей в запросе не влиял на ключ.
+func normalizeJSON(body []byte) []byte {
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return body
+	}
+	out, err := json.Marshal(doc)
+	if err != nil {
+		return body
+	}
+	return out
+}
+
+var unsafeChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+func sanitize(s string) string {
+	s = unsafeChars.ReplaceAllString(s, "_")
+	if s == "" {
+		return "root"
+	}
+	return s
+}
+
+func lastSegment(p string) string {
+	p = strings.Trim(p, "/")
+	if i := strings.LastIndex(p, "/"); i >= 0 {
+		return p[i+1:]
+	}
+	return p
+}
+
+func readBody(body io.ReadCloser) ([]byte, error) {
+	if body == nil {
+		return nil, nil
+	}
+	defer body.Close()
+	return io.ReadAll(body)
+}
+
+// Recorder пропускает запросы к поставщику и сохраняет каждую пару
+// запрос/ответ в dir. Секреты в URL и телах маскируются тем же
+// Redactor, что и в логах.
+type Recorder struct {
+	dir  string
+	next http.RoundTripper
+	mu   sync.Mutex
+}
+
+func NewRecorder(dir string, next http.RoundTripper) *Recorder {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &Recorder{dir: dir, next: next}
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readBody(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(reqBody))
+
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := readBody(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	redactor := logger.CurrentRedactor()
+
+	fixture := Fixture{
+		Request: Message{
+			Method: req.Method,
+			URL:    redactor.URL(req.URL),
+		},
+		Response: Message{
+			Status:      resp.StatusCode,
+			ContentType: resp.Header.Get("Content-Type"),
+		},
+	}
+	setBody(&fixture.Request, redactor.Body(reqBody))
+	setBody(&fixture.Response, redactor.Body(respBody))
+
+	if err := r.save(req, reqBody, fixture); err != nil {
+		// запись фикстуры не должна ломать сам запрос
+		logger.FromContext(req.Context()).Warn("Failed to record fixture",
+			zap.Error(err))
+	}
+
+	return resp, nil
+}
+
+func (r *Recorder) save(req *http.Request, reqBody []byte, fixture Fixture) error {
+	dir, name := key(req.Method, req.URL, reqBody)
+
+	var data bytes.Buffer
+	enc := json.NewEncoder(&data)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(fixture); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	path := filepath.Join(r.dir, dir)
+	if err := os.MkdirAll(path, 0o755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(path, name), data.Bytes(), 0o644)
+}
+
+// Replayer отвечает на запросы из сохранённых фикстур и никогда не
+// обращается в сеть. Запрос без фикстуры — ошибка, а не пустой ответ,
+// чтобы пропущенная запись была заметна сразу.
+type Replayer struct {
+	dir string
+}
+
+func NewReplayer(dir string) *Replayer {
+	return &Replayer{dir: dir}
+}
+
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readBody(req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	fixture, err := r.Load(req.Method, req.URL, reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	body := fixture.Response.body()
+
+	header := http.Header{}
+	if fixture.Response.ContentType != "" {
+		header.Set("Content-Type", fixture.Response.ContentType)
+	}
+
+	status := fixture.Response.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// Load читает фикстуру для запроса.
+func (r *Replayer) Load(method string, u *url.URL, body []byte) (*Fixture, error) {
+	dir, name := key(method, u, body)
+	path := filepath.Join(r.dir, dir, name)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no fixture for %s %s (%s)", method, logger.CurrentRedactor().URL(u), path)
+		}
+		return nil, err
+	}
+
+	var fixture Fixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("decode fixture %s: %w", path, err)
+	}
+
+	return &fixture, nil
+}
@@ -0,0 +1,62 @@
+package logger
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type requestIDKey struct{}
+
+type debugKey struct{}
+
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+func WithDebug(ctx context.Context) context.Context {
+	return context.WithValue(ctx, debugKey{}, true)
+}
+
+func DebugFromContext(ctx context.Context) bool {
+	debug, _ := ctx.Value(debugKey{}).(bool)
+	return debug
+}
+
+// FromContext возвращает логгер с request_id и trace_id/span_id текущего
+// спана, чтобы по логам можно было связать ошибку поставщика с запросом.
+func FromContext(ctx context.Context) *zap.Logger {
+	if ctx == nil {
+		return L
+	}
+
+	base := L
+	if DebugFromContext(ctx) && debugL != nil {
+		base = debugL
+	}
+
+	var fields []zap.Field
+
+	if id := RequestIDFromContext(ctx); id != "" {
+		fields = append(fields, zap.String(RequestIDKey, id))
+	}
+
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		fields = append(fields,
+			zap.String("trace_id", sc.TraceID().String()),
+			zap.String("span_id", sc.SpanID().String()),
+		)
+	}
+
+	if len(fields) == 0 {
+		return base
+	}
+
+	return base.With(fields...)
+}
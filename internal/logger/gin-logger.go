@@ -14,7 +14,7 @@ func GinLogger() gin.HandlerFunc {
 
 		latency := time.Since(start)
 
-		L.Info("HTTP request",
+		FromContext(c.Request.Context()).Info("HTTP request",
 			zap.Int("status", c.Writer.Status()),
 			zap.String("method", c.Request.Method),
 			zap.String("path", c.Request.URL.Path),
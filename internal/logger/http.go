@@ -2,12 +2,15 @@ package logger
 
 import (
 	"bytes"
+	"context"
 	"io"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
@@ -35,11 +38,22 @@ func sanitizeHeaders(h http.Header) http.Header {
 	safe.Del("Api-Key")
 	safe.Del("Token")
 
+	// в остальных заголовках маскируем известные секреты
+	r := CurrentRedactor()
+	for name, values := range safe {
+		for i, v := range values {
+			values[i] = r.Text(v)
+		}
+		safe[name] = values
+	}
+
 	return safe
 }
 
 func (l *LoggingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
 	start := time.Now()
+	ctx := req.Context()
+	log := FromContext(ctx)
 
 	// --- Request body ---
 	var reqBody []byte
@@ -52,17 +66,22 @@ func (l *LoggingRoundTripper) RoundTrip(req *http.Request) (*http.Response, erro
 	latency := time.Since(start)
 
 	if err != nil {
-		L.Error("API request failed",
+		fields := []zap.Field{
 			zap.String("method", req.Method),
-			zap.String("url", req.URL.String()),
+			zap.String("url", CurrentRedactor().URL(req.URL)),
 			zap.Any("headers", sanitizeHeaders(req.Header)),
 			zap.Duration("latency", latency),
 			zap.Error(err),
-		)
+		}
+		if captureBody(DebugFromContext(ctx), true) {
+			fields = append(fields, zap.ByteString("request_body", LimitBody(reqBody)))
+		}
+
+		log.Error("API request failed", fields...)
 		return nil, err
 	}
 
-	// --- Response body (КЛЮЧЕВОЕ МЕСТО) ---
+	// тело ответа читаем целиком и возвращаем обратно клиенту
 	var respBody []byte
 	if resp.Body != nil {
 		respBody, err = io.ReadAll(resp.Body)
@@ -70,35 +89,48 @@ func (l *LoggingRoundTripper) RoundTrip(req *http.Request) (*http.Response, erro
 			return resp, err
 		}
 
-		L.Info("RAW API RESPONSE",
-			zap.String("url", req.URL.String()),
-			zap.ByteString("raw_body", limitSize(respBody)),
-		)
-
-		// ВОЗВРАЩАЕМ body обратно
 		resp.Body.Close()
 		resp.Body = io.NopCloser(bytes.NewBuffer(respBody))
 	}
 
-	L.Info("API exchange",
+	failed := resp.StatusCode >= 400
+
+	fields := []zap.Field{
 		zap.String("method", req.Method),
-		zap.String("url", req.URL.String()),
+		zap.String("url", CurrentRedactor().URL(req.URL)),
 		zap.Any("headers", sanitizeHeaders(req.Header)),
 		zap.Int("status", resp.StatusCode),
 		zap.Duration("latency", latency),
-		zap.ByteString("request_body", limitSize(reqBody)),
-		zap.ByteString("response_body", limitSize(respBody)),
-	)
+		zap.Int("response_size", len(respBody)),
+	}
+	if captureBody(DebugFromContext(ctx), failed) {
+		fields = append(fields,
+			zap.ByteString("request_body", LimitBody(reqBody)),
+			zap.ByteString("response_body", LimitBody(respBody)),
+		)
+	}
+
+	if failed {
+		log.Warn("API exchange", fields...)
+	} else {
+		log.Info("API exchange", fields...)
+	}
 
 	return resp, nil
 }
 
-func limitSize(data []byte) []byte {
-	const max = 4096
-	if len(data) > max {
-		return append(data[:max], []byte("...truncated")...)
+// LimitBody маскирует и обрезает тело для записи в лог.
+func LimitBody(body []byte) []byte {
+	return limitSize(CurrentRedactor().Body(body))
+}
+
+// BodyField — поле лога с телом ответа поставщика с учётом политики
+// захвата тел. Если тело логировать не нужно, возвращает zap.Skip().
+func BodyField(ctx context.Context, key string, body []byte, failed bool) zap.Field {
+	if !captureBody(DebugFromContext(ctx), failed) {
+		return zap.Skip()
 	}
-	return data
+	return zap.ByteString(key, LimitBody(body))
 }
 
 const RequestIDKey = "request_id"
@@ -110,6 +142,28 @@ func RequestID() gin.HandlerFunc {
 		c.Set(RequestIDKey, requestID)
 		c.Writer.Header().Set("X-Request-ID", requestID)
 
+		ctx := WithRequestID(c.Request.Context(), requestID)
+		trace.SpanFromContext(ctx).SetAttributes(attribute.String(RequestIDKey, requestID))
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
+
+const DebugHeader = "X-Debug-Log"
+
+// DebugOverride включает отладочное логирование для одного запроса по
+// заголовку X-Debug-Log: 1. Действует на все логи с контекстом запроса,
+// включая обмен с поставщиками.
+func DebugOverride() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if opts.AllowDebugHeader {
+			switch c.GetHeader(DebugHeader) {
+			case "1", "true", "on":
+				c.Request = c.Request.WithContext(WithDebug(c.Request.Context()))
+			}
+		}
+
 		c.Next()
 	}
 }
@@ -1,6 +1,8 @@
 package logger
 
 import (
+	"fmt"
+	"math/rand"
 	"os"
 	"path/filepath"
 	"time"
@@ -12,23 +14,82 @@ import (
 
 var L *zap.Logger
 
-func Init(logDir string) error {
-	if err := os.MkdirAll(logDir, 0755); err != nil {
-		return err
+// debugL пишет в те же выходы, но без фильтра уровня — для запросов
+// с заголовком DebugHeader.
+var debugL *zap.Logger
+
+var level = zap.NewAtomicLevelAt(zap.DebugLevel)
+
+const (
+	OutputStdout = "stdout"
+	OutputFile   = "file"
+	OutputBoth   = "both"
+)
+
+const (
+	BodyCaptureOff     = "off"
+	BodyCaptureErrors  = "errors"
+	BodyCaptureSampled = "sampled"
+	BodyCaptureFull    = "full"
+)
+
+type Options struct {
+	Level  string
+	Output string
+	Dir    string
+
+	BodyCapture    string
+	BodySampleRate float64
+	MaxBodySize    int
+
+	AllowDebugHeader bool
+}
+
+var opts = Options{
+	BodyCapture: BodyCaptureFull,
+	MaxBodySize: 4096,
+}
+
+func Init(o Options) error {
+	if err := level.UnmarshalText([]byte(o.Level)); err != nil {
+		return fmt.Errorf("invalid log level %q: %w", o.Level, err)
 	}
 
-	logFile := filepath.Join(
-		logDir,
-		"app-"+time.Now().Format("2006-01-02")+".log",
-	)
+	switch o.BodyCapture {
+	case BodyCaptureOff, BodyCaptureErrors, BodyCaptureSampled, BodyCaptureFull:
+	default:
+		return fmt.Errorf("invalid body capture policy %q", o.BodyCapture)
+	}
+
+	var writers []zapcore.WriteSyncer
+
+	switch o.Output {
+	case OutputStdout:
+		writers = append(writers, zapcore.Lock(os.Stdout))
+	case OutputFile, OutputBoth:
+		if err := os.MkdirAll(o.Dir, 0755); err != nil {
+			return err
+		}
+
+		logFile := filepath.Join(
+			o.Dir,
+			"app-"+time.Now().Format("2006-01-02")+".log",
+		)
 
-	writer := zapcore.AddSync(&lumberjack.Logger{
-		Filename:   logFile,
-		MaxSize:    100, // MB
-		MaxBackups: 30,
-		MaxAge:     30, // days
-		Compress:   true,
-	})
+		writers = append(writers, zapcore.AddSync(&lumberjack.Logger{
+			Filename:   logFile,
+			MaxSize:    100, // MB
+			MaxBackups: 30,
+			MaxAge:     30, // days
+			Compress:   true,
+		}))
+
+		if o.Output == OutputBoth {
+			writers = append(writers, zapcore.Lock(os.Stdout))
+		}
+	default:
+		return fmt.Errorf("invalid log output %q", o.Output)
+	}
 
 	encoderConfig := zap.NewProductionEncoderConfig()
 	encoderConfig.TimeKey = "timestamp"
@@ -36,17 +97,59 @@ func Init(logDir string) error {
 	encoderConfig.LevelKey = "level"
 	encoderConfig.MessageKey = "message"
 
-	core := zapcore.NewCore(
-		zapcore.NewJSONEncoder(encoderConfig),
-		writer,
-		zap.DebugLevel,
-	)
+	newCore := func(enabler zapcore.LevelEnabler) zapcore.Core {
+		return &redactCore{Core: zapcore.NewCore(
+			zapcore.NewJSONEncoder(encoderConfig),
+			zapcore.NewMultiWriteSyncer(writers...),
+			enabler,
+		)}
+	}
 
-	L = zap.New(core,
+	options := []zap.Option{
 		zap.AddCaller(),
 		zap.AddStacktrace(zapcore.ErrorLevel),
-	)
+	}
+
+	L = zap.New(newCore(level), options...)
+	debugL = zap.New(newCore(zap.DebugLevel), options...)
+	opts = o
 
 	zap.ReplaceGlobals(L)
 	return nil
 }
+
+// SetLevel меняет уровень логирования без перезапуска.
+func SetLevel(l string) error {
+	return level.UnmarshalText([]byte(l))
+}
+
+// captureBody решает, попадут ли тела запроса/ответа в лог.
+func captureBody(debug, failed bool) bool {
+	if debug {
+		return true
+	}
+
+	switch opts.BodyCapture {
+	case BodyCaptureFull:
+		return true
+	case BodyCaptureErrors:
+		return failed
+	case BodyCaptureSampled:
+		return failed || rand.Float64() < opts.BodySampleRate
+	default:
+		return false
+	}
+}
+
+// limitSize обрезает тело до MaxBodySize. Исходный срез не изменяется:
+// он может быть телом ответа, которое ещё читает клиент.
+func limitSize(data []byte) []byte {
+	max := opts.MaxBodySize
+	if max <= 0 || len(data) <= max {
+		return data
+	}
+
+	out := make([]byte, 0, max+len("...truncated"))
+	out = append(out, data[:max]...)
+	return append(out, "...truncated"...)
+}
@@ -0,0 +1,259 @@
+package logger
+
+import (
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync/atomic"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const redactedValue = "[REDACTED]"
+
+// Redactor маскирует секреты в логах: значения параметров query string по
+// имени, поля JSON-тела по пути ("auth.password") и известные значения
+// секретов (токены из конфига) в любом месте строки.
+type Redactor struct {
+	queryParams map[string]bool
+	bodyPaths   [][]string
+	secrets     []string
+
+	queryRe *regexp.Regexp
+	fieldRe *regexp.Regexp
+}
+
+var redactor atomic.Pointer[Redactor]
+
+func init() {
+	redactor.Store(NewRedactor(
+		[]string{"token", "access_token", "api_key", "apikey", "password"},
+		[]string{"login", "password"},
+		nil,
+	))
+}
+
+// SetRedactor заменяет правила маскирования для всех логов.
+func SetRedactor(r *Redactor) {
+	redactor.Store(r)
+}
+
+func CurrentRedactor() *Redactor {
+	return redactor.Load()
+}
+
+func NewRedactor(queryParams, bodyPaths, secrets []string) *Redactor {
+	r := &Redactor{
+		queryParams: map[string]bool{},
+	}
+
+	var queryNames, fieldNames []string
+
+	for _, name := range queryParams {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		r.queryParams[name] = true
+		queryNames = append(queryNames, regexp.QuoteMeta(name))
+	}
+
+	for _, path := range bodyPaths {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		parts := strings.Split(path, ".")
+		r.bodyPaths = append(r.bodyPaths, parts)
+		fieldNames = append(fieldNames, regexp.QuoteMeta(parts[len(parts)-1]))
+	}
+
+	for _, s := range secrets {
+		// слишком короткие значения дали бы ложные срабатывания по всему логу
+		if len(s) >= 4 {
+			r.secrets = append(r.secrets, s)
+		}
+	}
+
+	if len(queryNames) > 0 {
+		r.queryRe = regexp.MustCompile(`(?i)([?&](?:` + strings.Join(queryNames, "|") + `)=)[^&\s"']*`)
+	}
+	if len(fieldNames) > 0 {
+		r.fieldRe = regexp.MustCompile(`("(?:` + strings.Join(fieldNames, "|") + `)"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+	}
+
+	return r
+}
+
+// URL возвращает адрес с замаскированными параметрами query string.
+func (r *Redactor) URL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+
+	q := u.Query()
+	changed := false
+	for key := range q {
+		if r.queryParams[strings.ToLower(key)] {
+			q.Set(key, redactedValue)
+			changed = true
+		}
+	}
+
+	if !changed {
+		return r.Text(u.String())
+	}
+
+	safe := *u
+	safe.RawQuery = q.Encode()
+	return r.Text(safe.String())
+}
+
+// Body маскирует поля JSON-тела по настроенным путям. Тела, которые не
+// разбираются как JSON, проходят через Text.
+func (r *Redactor) Body(body []byte) []byte {
+	if len(body) == 0 || len(r.bodyPaths) == 0 {
+		return []byte(r.Text(string(body)))
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return []byte(r.Text(string(body)))
+	}
+
+	changed := false
+	for _, path := range r.bodyPaths {
+		if redactPath(doc, path) {
+			changed = true
+		}
+	}
+
+	if !changed {
+		return []byte(r.Text(string(body)))
+	}
+
+	out, err := json.Marshal(doc)
+	if err != nil {
+		return []byte(r.Text(string(body)))
+	}
+
+	return []byte(r.Text(string(out)))
+}
+
+func redactPath(node interface{}, path []string) bool {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		child, ok := v[path[0]]
+		if !ok {
+			return false
+		}
+		if len(path) == 1 {
+			v[path[0]] = redactedValue
+			return true
+		}
+		return redactPath(child, path[1:])
+	case []interface{}:
+		// массивы прозрачны для пути: "items.password" покрывает каждый элемент
+		changed := false
+		for _, item := range v {
+			if redactPath(item, path) {
+				changed = true
+			}
+		}
+		return changed
+	default:
+		return false
+	}
+}
+
+// Text — последняя линия защиты для произвольных строк: URL внутри текста,
+// JSON-фрагменты и буквальные значения секретов.
+func (r *Redactor) Text(s string) string {
+	if s == "" {
+		return s
+	}
+
+	if r.queryRe != nil {
+		s = r.queryRe.ReplaceAllString(s, "${1}"+redactedValue)
+	}
+	if r.fieldRe != nil {
+		s = r.fieldRe.ReplaceAllString(s, `${1}"`+redactedValue+`"`)
+	}
+	for _, secret := range r.secrets {
+		s = strings.ReplaceAll(s, secret, redactedValue)
+	}
+
+	return s
+}
+
+// RedactError возвращает ошибку с замаскированным текстом. *url.Error
+// http-клиента содержит полный адрес запроса вместе с токенами; его тип
+// сохраняется, чтобы классификация таймаутов продолжала работать.
+func RedactError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	r := CurrentRedactor()
+
+	if urlErr, ok := err.(*url.Error); ok {
+		safe := *urlErr
+		safe.URL = r.Text(urlErr.URL)
+		return &safe
+	}
+
+	return &redactedError{msg: r.Text(err.Error()), err: err}
+}
+
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string { return e.msg }
+func (e *redactedError) Unwrap() error { return e.err }
+
+// redactCore маскирует сообщение и строковые поля каждой записи лога.
+type redactCore struct {
+	zapcore.Core
+}
+
+func (c *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactCore{Core: c.Core.With(redactFields(fields))}
+}
+
+func (c *redactCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *redactCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	ent.Message = CurrentRedactor().Text(ent.Message)
+	return c.Core.Write(ent, redactFields(fields))
+}
+
+func redactFields(fields []zapcore.Field) []zapcore.Field {
+	r := CurrentRedactor()
+	out := make([]zapcore.Field, len(fields))
+
+	for i, f := range fields {
+		switch f.Type {
+		case zapcore.StringType:
+			f.String = r.Text(f.String)
+		case zapcore.ByteStringType:
+			if b, ok := f.Interface.([]byte); ok {
+				f.Interface = r.Body(b)
+			}
+		case zapcore.ErrorType:
+			if err, ok := f.Interface.(error); ok && err != nil {
+				f.Interface = &redactedError{msg: r.Text(err.Error()), err: err}
+			}
+		}
+		out[i] = f
+	}
+
+	return out
+}
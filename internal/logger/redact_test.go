@@ -0,0 +1,208 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// namedSecrets скрываются по имени параметра query, поля тела или
+// заголовка; редактор о самих значениях не знает, иначе тест не заметит
+// поломку этих правил.
+var namedSecrets = []string{
+	"getchips-token-5f1c",
+	"efind-access-9a7d",
+	"promelec-pass-31b2",
+}
+
+// literalSecrets попадают в произвольный текст и находятся только по
+// значению — как секреты из конфигурации.
+var literalSecrets = []string{
+	"session-secret-42aa",
+	"customer-key-77e0",
+}
+
+// captureLogs направляет L и debugL в буфер через тот же redactCore, что
+// и Init, с полной записью тел.
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	core := &redactCore{Core: zapcore.NewCore(
+		zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()),
+		zapcore.AddSync(&buf),
+		zap.DebugLevel,
+	)}
+
+	prevL, prevDebug, prevOpts, prevRedactor := L, debugL, opts, CurrentRedactor()
+	t.Cleanup(func() {
+		L, debugL, opts = prevL, prevDebug, prevOpts
+		SetRedactor(prevRedactor)
+	})
+
+	L = zap.New(core)
+	debugL = L
+	opts = Options{BodyCapture: BodyCaptureFull, MaxBodySize: 4096, AllowDebugHeader: true}
+
+	SetRedactor(NewRedactor(
+		[]string{"token", "access_token"},
+		[]string{"login", "password"},
+		literalSecrets,
+	))
+
+	return &buf
+}
+
+func assertNoSecrets(t *testing.T, logs string) {
+	t.Helper()
+
+	if logs == "" {
+		t.Fatal("nothing was logged")
+	}
+	for _, secret := range append(namedSecrets, literalSecrets...) {
+		if strings.Contains(logs, secret) {
+			t.Errorf("secret %q found in logs:\n%s", secret, logs)
+		}
+	}
+}
+
+func TestLoggingRoundTripperRedactsSecrets(t *testing.T) {
+	buf := captureLogs(t)
+
+	for _, status := range []int{http.StatusOK, http.StatusUnauthorized} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			supplier := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				// поставщики иногда повторяют параметры запроса в ответе
+				w.WriteHeader(status)
+				fmt.Fprintf(w, `{"echo":"/search?token=%s","password":"%s"}`, namedSecrets[0], namedSecrets[2])
+			}))
+			defer supplier.Close()
+
+			body := fmt.Sprintf(`{"login":"user","password":%q,"method":"items_data_find"}`, namedSecrets[2])
+			req, err := http.NewRequest(http.MethodPost,
+				supplier.URL+"/search?input=NE555&token="+namedSecrets[0]+"&access_token="+namedSecrets[1],
+				strings.NewReader(body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Authorization", "Bearer "+namedSecrets[0])
+			req.Header.Set("X-API-Key", literalSecrets[1])
+			req.Header.Set("X-Supplier-Session", "session "+literalSecrets[0])
+
+			resp, err := (&http.Client{Transport: NewLoggingRoundTripper(nil)}).Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			// ответ отдаётся клиенту без изменений
+			got, _ := io.ReadAll(resp.Body)
+			if !strings.Contains(string(got), namedSecrets[0]) {
+				t.Errorf("response body was altered: %s", got)
+			}
+		})
+	}
+
+	t.Run("transport error", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "http://127.0.0.1:1/?token="+namedSecrets[0], nil)
+		req.Header.Set("X-Supplier-Session", literalSecrets[0])
+
+		if _, err := NewLoggingRoundTripper(nil).RoundTrip(req); err == nil {
+			t.Fatal("expected connection error")
+		}
+	})
+
+	assertNoSecrets(t, buf.String())
+}
+
+func TestGinLoggerRedactsSecrets(t *testing.T) {
+	buf := captureLogs(t)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestID(), DebugOverride(), GinLogger())
+	router.POST("/process", func(c *gin.Context) {
+		raw, _ := io.ReadAll(c.Request.Body)
+
+		log := FromContext(c.Request.Context())
+		log.Debug("incoming body", BodyField(c.Request.Context(), "body", raw, false))
+		log.Error("supplier failed", zap.Error(RedactError(fmt.Errorf(
+			"Get \"https://api.example/search?token=%s\": timeout", namedSecrets[0]))))
+		log.Info("using key " + c.GetHeader("X-API-Key"))
+
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+
+	body := fmt.Sprintf(`{"customer":"c1","password":%q}`, namedSecrets[2])
+	req := httptest.NewRequest(http.MethodPost, "/process?token="+namedSecrets[0], strings.NewReader(body))
+	req.Header.Set("X-API-Key", literalSecrets[1])
+	req.Header.Set(DebugHeader, "1")
+	req.Header.Set("User-Agent", "bom-client/1.0 "+literalSecrets[0])
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d", rec.Code)
+	}
+
+	logs := buf.String()
+	if !strings.Contains(logs, "HTTP request") {
+		t.Fatalf("request was not logged:\n%s", logs)
+	}
+	assertNoSecrets(t, logs)
+}
+
+// Каждое правило проверяется отдельно: в логах они страхуют друг друга,
+// и поломка одного там не видна.
+func TestRedactorByName(t *testing.T) {
+	r := NewRedactor([]string{"token"}, []string{"password", "items.secret"}, nil)
+
+	u, _ := url.Parse("https://api.example/search?input=NE555&token=abc123")
+	if got, want := r.URL(u), "https://api.example/search?input=NE555&token=[REDACTED]"; got != want {
+		t.Errorf("URL = %s, want %s", got, want)
+	}
+
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{
+			name: "query in text",
+			got:  r.Text(`Get "https://api.example/search?input=NE555&TOKEN=abc123": timeout`),
+			want: `Get "https://api.example/search?input=NE555&TOKEN=[REDACTED]": timeout`,
+		},
+		{
+			name: "field in text",
+			got:  r.Text(`bad request {"login":"u","password": "p\"1"}`),
+			want: `bad request {"login":"u","password": "[REDACTED]"}`,
+		},
+		{
+			// числовое значение видит только разбор по пути, не fieldRe
+			name: "body path through arrays",
+			got:  string(r.Body([]byte(`{"items":[{"id":1,"secret":4242},{"id":2}],"note":"secret"}`))),
+			want: `{"items":[{"id":1,"secret":"[REDACTED]"},{"id":2}],"note":"secret"}`,
+		},
+		{
+			name: "body that is not JSON",
+			got:  string(r.Body([]byte(`password=p1&token=abc123`))),
+			want: `password=p1&token=[REDACTED]`,
+		},
+	}
+
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s:\n got  %s\n want %s", tt.name, tt.got, tt.want)
+		}
+	}
+}
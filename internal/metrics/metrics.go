@@ -0,0 +1,107 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Метки держим низкой кардинальности: поставщик, класс ошибки, маршрут.
+// MPN и прочие пользовательские значения в метки не попадают.
+
+const namespace = "pricing"
+
+var (
+	SupplierRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "supplier_requests_total",
+		Help:      "Supplier API calls by result class.",
+	}, []string{"supplier", "result"})
+
+	SupplierLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "supplier_request_duration_seconds",
+		Help:      "Supplier API call latency.",
+		Buckets:   []float64{0.1, 0.25, 0.5, 1, 2, 5, 10, 15, 30},
+	}, []string{"supplier"})
+
+	SupplierErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "supplier_errors_total",
+		Help:      "Supplier API errors by class.",
+	}, []string{"supplier", "class"})
+
+	SupplierOffers = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "supplier_offers_total",
+		Help:      "Offers returned by supplier after formatting.",
+	}, []string{"supplier"})
+
+	ProcessorQueueDepth = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "processor_queue_depth",
+		Help:      "BOM rows waiting for a worker.",
+	})
+
+	ProcessorActiveWorkers = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "processor_active_workers",
+		Help:      "Workers currently querying suppliers.",
+	})
+
+	BOMRowsProcessed = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "bom_rows_processed_total",
+		Help:      "BOM rows processed by workers.",
+	})
+
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by route and status.",
+	}, []string{"method", "route", "status"})
+
+	HTTPDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route.",
+		Buckets:   []float64{0.05, 0.1, 0.5, 1, 5, 15, 30, 60, 120, 300},
+	}, []string{"method", "route"})
+)
+
+// ObserveSupplierCall фиксирует один вызов поставщика. class пустой при успехе.
+func ObserveSupplierCall(supplier string, latency time.Duration, class string) {
+	SupplierLatency.WithLabelValues(supplier).Observe(latency.Seconds())
+
+	if class == "" {
+		SupplierRequests.WithLabelValues(supplier, "ok").Inc()
+		return
+	}
+
+	SupplierRequests.WithLabelValues(supplier, "error").Inc()
+	SupplierErrors.WithLabelValues(supplier, class).Inc()
+}
+
+func GinMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		// FullPath — шаблон маршрута (/quotes/:number), а не сырой путь
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		HTTPRequests.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		HTTPDuration.WithLabelValues(c.Request.Method, route).Observe(time.Since(start).Seconds())
+	}
+}
+
+func Handler() gin.HandlerFunc {
+	return gin.WrapH(promhttp.Handler())
+}
@@ -0,0 +1,118 @@
+package processor
+
+import (
+	"context"
+
+	"dynamic-pricing-tool-ru/internal/logger"
+	"dynamic-pricing-tool-ru/internal/storage"
+	"dynamic-pricing-tool-ru/internal/types"
+
+	"go.uber.org/zap"
+)
+
+// MaxAlternativesPerRow ограничивает число аналогов, которые ищутся у
+// поставщиков для одной строки.
+const MaxAlternativesPerRow = 5
+
+type substituteFor struct {
+	part types.PartData
+	ref  types.CrossReference
+}
+
+// SetCrossRefs включает поиск аналогов для строк без предложений в наличии.
+func (p *Processor) SetCrossRefs(crossRefs *storage.CrossRefs) {
+	p.crossRefs = crossRefs
+}
+
+// AlternativeSearch — аналоги, которые нужно поискать у поставщиков.
+// Каждый поиск стоит столько же, сколько строка BOM, поэтому план
+// строится заранее: по Rows вызывающая сторона списывает квоту.
+type AlternativeSearch struct {
+	searches    []types.PartData
+	substitutes map[string][]substituteFor
+}
+
+// Rows — число дополнительных поисков у поставщиков.
+func (a *AlternativeSearch) Rows() int {
+	if a == nil {
+		return 0
+	}
+	return len(a.searches)
+}
+
+// PlanAlternatives выбирает аналоги из таблицы для строк, где ни одно
+// предложение не покрывает запрошенное количество. Ошибка чтения таблицы
+// не мешает основному результату и только логируется.
+func (p *Processor) PlanAlternatives(ctx context.Context, parts []types.PartData, offers []types.UnifiedOffer) *AlternativeSearch {
+	if p.crossRefs == nil {
+		return nil
+	}
+
+	inStock := map[string]bool{}
+	for _, o := range offers {
+		if o.Stock >= o.RequestedQty {
+			inStock[partKey(o.RequestedMPN, o.RequestedQty)] = true
+		}
+	}
+
+	// один аналог может подойти нескольким строкам — ищем его один раз
+	plan := &AlternativeSearch{substitutes: map[string][]substituteFor{}}
+
+	for _, part := range uniqueParts(parts) {
+		if inStock[partKey(part.PartNumber, part.Qty)] {
+			continue
+		}
+
+		refs, err := p.crossRefs.Lookup(ctx, part.PartNumber)
+		if err != nil {
+			logger.FromContext(ctx).Warn("cross reference lookup failed",
+				zap.String("mpn", part.PartNumber),
+				zap.Error(err),
+			)
+			continue
+		}
+		if len(refs) > MaxAlternativesPerRow {
+			refs = refs[:MaxAlternativesPerRow]
+		}
+
+		for _, ref := range refs {
+			key := partKey(ref.Alternative, part.Qty)
+			if _, ok := plan.substitutes[key]; !ok {
+				plan.searches = append(plan.searches, types.PartData{
+					PartNumber: ref.Alternative,
+					Qty:        part.Qty,
+					RowIndex:   part.RowIndex,
+				})
+			}
+			plan.substitutes[key] = append(plan.substitutes[key], substituteFor{part: part, ref: ref})
+		}
+	}
+
+	return plan
+}
+
+// SearchAlternatives опрашивает поставщиков по плану. Найденные офферы
+// привязаны к исходной строке через RequestedMPN и помечены Substitute.
+func (p *Processor) SearchAlternatives(ctx context.Context, plan *AlternativeSearch) ([]types.UnifiedOffer, error) {
+	if plan.Rows() == 0 {
+		return nil, nil
+	}
+
+	found, err := p.ProcessParts(ctx, plan.searches)
+
+	var result []types.UnifiedOffer
+	for _, o := range found {
+		for _, s := range plan.substitutes[partKey(o.RequestedMPN, o.RequestedQty)] {
+			alt := o
+			alt.RequestedMPN = s.part.PartNumber
+			alt.Substitute = &types.Substitute{
+				For:           s.part.PartNumber,
+				Compatibility: s.ref.Compatibility,
+				Note:          s.ref.Note,
+			}
+			result = append(result, alt)
+		}
+	}
+
+	return result, err
+}
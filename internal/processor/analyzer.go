@@ -1,83 +1,137 @@
 package processor
 
 import (
+	"context"
+	"sort"
+
 	"dynamic-pricing-tool-ru/internal/types"
+	"dynamic-pricing-tool-ru/internal/utils"
 )
 
-func AnalyzeResults(results []types.CombinedResult) *types.AnalysisResult {
-	var analysis types.AnalysisResult
+// AnalyzeResults строит отчёт по обработанной BOM: покрытие и наличие по
+// поставщикам, строки без предложений, стоимость BOM по лучшим ценам и при
+// закупке только у одного поставщика. Лучшая цена выбирается без учёта
+// склада — как и в сортировке строк в GroupByPart.
+func (p *Processor) AnalyzeResults(ctx context.Context, parts []types.PartData, offers []types.UnifiedOffer, currency string) *types.AnalysisResult {
+	if currency == "" {
+		currency = DefaultSummaryCurrency
+	}
+	pricing := p.pricingFor(ctx)
 
-	for _, result := range results {
-		analysis.TotalParts++
+	byRow := map[string][]types.UnifiedOffer{}
+	for _, o := range offers {
+		// аналоги не закрывают строку: в отчёте только запрошенные детали
+		if o.Substitute != nil {
+			continue
+		}
+		key := partKey(o.RequestedMPN, o.RequestedQty)
+		byRow[key] = append(byRow[key], o)
+	}
 
-		if result.Getchips != nil {
-			analysis.GetchipsSuccess++
-			if result.Getchips.AvailableQty > 0 {
-				analysis.GetchipsInStock++
-			}
+	suppliers := map[string]*types.SupplierAnalysis{}
+	supplier := func(source string) *types.SupplierAnalysis {
+		s, ok := suppliers[source]
+		if !ok {
+			s = &types.SupplierAnalysis{Source: source}
+			suppliers[source] = s
 		}
+		return s
+	}
 
-		if result.Efind != nil {
-			analysis.EfindSuccess++
-			if result.Efind.InStock {
-				analysis.EfindInStock++
-			}
+	// включённые поставщики попадают в отчёт, даже если не нашли ни одной
+	// строки; остальные добавляются по офферам
+	for _, source := range p.combinedClient.Enabled() {
+		supplier(source)
+	}
+
+	analysis := &types.AnalysisResult{
+		TotalRows:         len(parts),
+		RowsWithoutOffers: []int{},
+		Currency:          currency,
+	}
+
+	for _, part := range parts {
+		rowOffers := byRow[partKey(part.PartNumber, part.Qty)]
+		if len(rowOffers) == 0 {
+			analysis.RowsWithoutOffers = append(analysis.RowsWithoutOffers, part.RowIndex)
+			continue
 		}
+		analysis.RowsWithOffers++
+
+		// лучшая цена за штуку по каждому поставщику в этой строке
+		best := map[string]float64{}
+		covered := map[string]bool{}
+		inStock := map[string]bool{}
+
+		for _, o := range rowOffers {
+			covered[o.Source] = true
+			if o.Stock >= part.Qty {
+				inStock[o.Source] = true
+			}
 
-		if result.Getchips != nil && result.Efind != nil {
-			analysis.BothAPIsSuccess++
+			price := unitPrice(o, part.Qty, currency, pricing)
+			if price < 0 {
+				// цена есть, но не пересчитывается в валюту отчёта: такой
+				// оффер не выбрасываем молча, иначе сравнение шло бы только
+				// между поставщиками в одной валюте
+				if pb, ok := PriceBreakForQty(o.PriceBreaks, part.Qty); ok && pb.Price > 0 {
+					supplier(o.Source).UnconvertedOffers++
+					analysis.Unconverted = append(analysis.Unconverted, types.UnconvertedOffer{
+						Row:        part.RowIndex,
+						MPN:        o.MPN,
+						Source:     o.Source,
+						SellerName: o.SellerName,
+						Price:      pb.Price,
+						Currency:   o.Currency,
+					})
+				}
+				continue
+			}
+			if current, ok := best[o.Source]; !ok || price < current {
+				best[o.Source] = price
+			}
+		}
 
-			// Сравниваем цены (пример бизнес-логики)
-			if result.Getchips.Price > 0 && result.Efind.Price > 0 {
-				// Конвертируем в одну валюту для сравнения
-				priceComparison := comparePrices(result.Getchips, result.Efind)
-				analysis.PriceComparisons = append(analysis.PriceComparisons, priceComparison)
+		for source := range covered {
+			s := supplier(source)
+			s.RowsCovered++
+			if inStock[source] {
+				s.RowsInStock++
 			}
 		}
-	}
 
-	return &analysis
-}
+		if len(best) == 0 {
+			continue
+		}
+		analysis.PricedRows++
 
-func comparePrices(getchips *types.SimplifiedGetchipsData, efind *types.SimplifiedEfindData) types.PriceComparison {
-	comparison := types.PriceComparison{
-		PartNumber: getchips.PartNumber,
-	}
+		winner := ""
+		for source, price := range best {
+			s := supplier(source)
+			s.PricedRows++
+			s.TotalCost += price * float64(part.Qty)
 
-	// Простая логика сравнения
-	if getchips.Currency == efind.Currency {
-		comparison.GetchipsPrice = getchips.Price
-		comparison.EfindPrice = efind.Price
-
-		if getchips.Price < efind.Price {
-			comparison.BetterPrice = "getchips"
-			comparison.DifferencePercent = (efind.Price - getchips.Price) / efind.Price * 100
-		} else if efind.Price < getchips.Price {
-			comparison.BetterPrice = "efind"
-			comparison.DifferencePercent = (getchips.Price - efind.Price) / getchips.Price * 100
-		} else {
-			comparison.BetterPrice = "equal"
+			if winner == "" || price < best[winner] || (price == best[winner] && source < winner) {
+				winner = source
+			}
 		}
+		supplier(winner).Wins++
+		analysis.BestPriceTotal += best[winner] * float64(part.Qty)
 	}
 
-	return comparison
-}
+	analysis.BestPriceTotal = utils.Round(analysis.BestPriceTotal, 2)
 
-// Дополнительные типы для анализа
-type AnalysisResult struct {
-	TotalParts       int
-	GetchipsSuccess  int
-	GetchipsInStock  int
-	EfindSuccess     int
-	EfindInStock     int
-	BothAPIsSuccess  int
-	PriceComparisons []PriceComparison
-}
+	for _, s := range suppliers {
+		s.TotalCost = utils.Round(s.TotalCost, 2)
+		if analysis.TotalRows > 0 {
+			s.CoverageRate = utils.Round(float64(s.RowsCovered)/float64(analysis.TotalRows), 4)
+			s.InStockRate = utils.Round(float64(s.RowsInStock)/float64(analysis.TotalRows), 4)
+		}
+		analysis.Suppliers = append(analysis.Suppliers, *s)
+	}
+	sort.Slice(analysis.Suppliers, func(i, j int) bool {
+		return analysis.Suppliers[i].Source < analysis.Suppliers[j].Source
+	})
 
-type PriceComparison struct {
-	PartNumber        string
-	GetchipsPrice     float64
-	EfindPrice        float64
-	BetterPrice       string
-	DifferencePercent float64
+	return analysis
 }
@@ -0,0 +1,59 @@
+package processor
+
+import (
+	"dynamic-pricing-tool-ru/internal/storage"
+	"dynamic-pricing-tool-ru/internal/types"
+)
+
+// Enrich дополняет пустые атрибуты товара (описание, категория, корпус,
+// изображение, производитель) значениями из офферов других
+// поставщиков с тем же нормализованным MPN. Атрибуты самого предложения —
+// цена, склад, упаковка — не переносятся.
+func Enrich(offers []types.UnifiedOffer) {
+	groups := map[string][]int{}
+	for i, o := range offers {
+		key := storage.NormalizeMPN(o.MPN)
+		if key == "" {
+			continue
+		}
+		groups[key] = append(groups[key], i)
+	}
+
+	for _, idx := range groups {
+		if len(idx) < 2 {
+			continue
+		}
+
+		var ref types.UnifiedOffer
+		for _, i := range idx {
+			o := &offers[i]
+			fill(&ref.Manufacturer, o.Manufacturer)
+			fill(&ref.Description, o.Description)
+			fill(&ref.ImageURL, o.ImageURL)
+			fill(&ref.Package, o.Package)
+			// ID и название категории берём из одного источника
+			if ref.CategoryName == "" && o.CategoryName != "" {
+				ref.CategoryID = o.CategoryID
+				ref.CategoryName = o.CategoryName
+			}
+		}
+
+		for _, i := range idx {
+			o := &offers[i]
+			fill(&o.Manufacturer, ref.Manufacturer)
+			fill(&o.Description, ref.Description)
+			fill(&o.ImageURL, ref.ImageURL)
+			fill(&o.Package, ref.Package)
+			if o.CategoryName == "" {
+				o.CategoryID = ref.CategoryID
+				o.CategoryName = ref.CategoryName
+			}
+		}
+	}
+}
+
+func fill(dst *string, value string) {
+	if *dst == "" {
+		*dst = value
+	}
+}
@@ -22,6 +22,21 @@ func toInt(v interface{}) int {
 	}
 }
 
+// efindPrice разбирает ступень Efind — кортеж [кол-во, валюта, цена].
+// Ступени другой формы и с ценой не числом пропускаются.
+func efindPrice(p []interface{}) (types.PriceBreak, bool) {
+	if len(p) < 3 {
+		return types.PriceBreak{}, false
+	}
+
+	price, ok := p[2].(float64)
+	if !ok {
+		return types.PriceBreak{}, false
+	}
+
+	return types.PriceBreak{Quantity: toInt(p[0]), Price: price}, true
+}
+
 func FormatGetchipsData(raw *types.GetchipsResponse, requestedMPN string, requestedQty int) []types.UnifiedOffer {
 	if raw == nil {
 		return nil
@@ -55,9 +70,10 @@ func FormatGetchipsData(raw *types.GetchipsResponse, requestedMPN string, reques
 			RequestedMPN: requestedMPN,
 			RequestedQty: requestedQty,
 
-			Manufacturer:   d.Brand,
-			SellerName:     "Getchips",
-			SellerVerified: true,
+			Manufacturer: d.Brand,
+			SellerName:   "Getchips",
+
+			Packaging: d.Packaging,
 
 			Stock:        d.Quantity,
 			Status:       "Найдено",
@@ -88,20 +104,9 @@ func FormatEfindData(raw *types.EfindResponse, requestedMPN string, requestedQty
 			// Собираем pricebreaks
 			var pbs []types.PriceBreak
 			for _, p := range row.Price {
-				if len(p) < 3 {
-					continue
+				if pb, ok := efindPrice(p); ok {
+					pbs = append(pbs, pb)
 				}
-
-				qty := toInt(p[0])
-				price, ok := p[2].(float64)
-				if !ok {
-					continue
-				}
-
-				pbs = append(pbs, types.PriceBreak{
-					Quantity: qty,
-					Price:    price,
-				})
 			}
 
 			priceBreaks := buildPriceBreaks(pbs, row.Cur)
@@ -122,7 +127,10 @@ func FormatEfindData(raw *types.EfindResponse, requestedMPN string, requestedQty
 
 				SellerName:     stock.StockData.Title,
 				SellerHomepage: stock.StockData.Site,
-				SellerVerified: true,
+				SellerStockID:  stock.StockID,
+				// перекупщики Efind проверенными не считаются, пока их
+				// не отметит правило продавца
+				SellerVerified: false,
 
 				Stock:    availableQty,
 				Status:   "Найдено",
@@ -167,6 +175,11 @@ func FormatPromelecData(data types.PromelecResponse, requestedMPN string, reques
 				RequestedMPN: requestedMPN,
 				RequestedQty: requestedQty,
 				Manufacturer: item.ProducerName,
+				CategoryID:   item.CategoryID,
+				CategoryName: item.CategoryName,
+				Description:  item.Description,
+				ImageURL:     item.PhotoURL,
+				Package:      item.Package,
 				SellerName:   "Promelec",
 				Stock:        item.Quant,
 				Status:       "Найдено",
@@ -185,6 +198,8 @@ func FormatPromelecData(data types.PromelecResponse, requestedMPN string, reques
 
 			delivery := formatDeliveryTime(v.Delivery, "1-2 недели")
 
+			// ступени товара относятся к складу самого Promelec, а не к
+			// стороннему складу: без своих ступеней оффер остаётся без цены
 			var priceBreaks []types.PriceBreak
 			for _, pb := range v.PriceBreaks {
 				priceBreaks = append(priceBreaks, types.PriceBreak{
@@ -194,15 +209,25 @@ func FormatPromelecData(data types.PromelecResponse, requestedMPN string, reques
 				})
 			}
 
+			basePrice := 0.0
+			if len(priceBreaks) > 0 {
+				basePrice = priceBreaks[0].Price
+			}
+
 			offers = append(offers, types.UnifiedOffer{
 				MPN:          item.Name,
 				RequestedMPN: requestedMPN,
 				RequestedQty: requestedQty,
 				Manufacturer: item.ProducerName,
+				CategoryID:   item.CategoryID,
+				CategoryName: item.CategoryName,
+				Description:  item.Description,
+				ImageURL:     item.PhotoURL,
+				Package:      item.Package,
 				SellerName:   "Promelec",
 				Stock:        v.Quant,
 				Status:       "Найдено",
-				Price:        v.PriceBreaks[0].Price,
+				Price:        basePrice,
 				Currency:     "RUB",
 				PriceBreaks:  buildPriceBreaks(priceBreaks, "RUB"),
 				DeliveryTime: "1-2 недели", // верхний уровень всегда default
@@ -245,14 +270,20 @@ func CompareAndSelectBest(getchips *types.SimplifiedGetchipsData, efind *types.S
 const (
 	deliveryCoef = 1.27
 	//markup       = 1.18
+
+	DefaultMarkupCoef = 1.10
 )
 
 func buildPriceBreaks(priceBreaks []types.PriceBreak, currency string) []types.UnifiedPriceBreak {
+	return buildPriceBreaksWithMarkup(priceBreaks, currency, DefaultMarkupCoef)
+}
+
+func buildPriceBreaksWithMarkup(priceBreaks []types.PriceBreak, currency string, markupCoef float64) []types.UnifiedPriceBreak {
 	var result []types.UnifiedPriceBreak
 
 	for _, pb := range priceBreaks {
 		base := pb.Price
-		markup := base * 1.10
+		markup := base * markupCoef
 		targetPurch := base * 0.82
 		costDelivery := targetPurch + deliveryCoef
 		targetSales := costDelivery + markup
@@ -0,0 +1,144 @@
+package processor
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yosuke-furukawa/json5/encoding/json5"
+
+	"dynamic-pricing-tool-ru/internal/types"
+)
+
+// Эталоны форматтеров поставщиков лежат в testdata/golden/<поставщик>:
+//
+//	<case>.input.json   {"mpn": "...", "qty": 10, "response": <ответ поставщика>}
+//	<case>.golden.json  ожидаемый []UnifiedOffer
+//
+// go test ./internal/processor -run TestFormatters -update перезаписывает
+// эталоны текущим результатом; изменения в них нужно просмотреть в диффе.
+var update = flag.Bool("update", false, "rewrite golden files with the current output")
+
+const goldenDir = "../../testdata/golden"
+
+// formatters разбирают входной файл и вызывают форматтер поставщика.
+// Ответы декодируются тем же способом, что и в клиентах.
+var formatters = map[string]func(data []byte) ([]types.UnifiedOffer, error){
+	"getchips": func(data []byte) ([]types.UnifiedOffer, error) {
+		var in struct {
+			MPN      string                 `json:"mpn"`
+			Qty      int                    `json:"qty"`
+			Response types.GetchipsResponse `json:"response"`
+		}
+		if err := json5.Unmarshal(data, &in); err != nil {
+			return nil, err
+		}
+		return FormatGetchipsData(&in.Response, in.MPN, in.Qty), nil
+	},
+	"efind": func(data []byte) ([]types.UnifiedOffer, error) {
+		var in struct {
+			MPN      string              `json:"mpn"`
+			Qty      int                 `json:"qty"`
+			Response types.EfindResponse `json:"response"`
+		}
+		if err := json5.Unmarshal(data, &in); err != nil {
+			return nil, err
+		}
+		return FormatEfindData(&in.Response, in.MPN, in.Qty), nil
+	},
+	"promelec": func(data []byte) ([]types.UnifiedOffer, error) {
+		var in struct {
+			MPN      string                 `json:"mpn"`
+			Qty      int                    `json:"qty"`
+			Response types.PromelecResponse `json:"response"`
+		}
+		if err := json.Unmarshal(data, &in); err != nil {
+			return nil, err
+		}
+		return FormatPromelecData(in.Response, in.MPN, in.Qty), nil
+	},
+}
+
+func TestFormatters(t *testing.T) {
+	for supplier, format := range formatters {
+		inputs, _ := filepath.Glob(filepath.Join(goldenDir, supplier, "*.input.json"))
+		if len(inputs) == 0 {
+			t.Errorf("no golden cases for %s in %s", supplier, goldenDir)
+		}
+
+		for _, input := range inputs {
+			name := supplier + "/" + strings.TrimSuffix(filepath.Base(input), ".input.json")
+
+			t.Run(name, func(t *testing.T) {
+				data, err := os.ReadFile(input)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				offers, err := format(data)
+				if err != nil {
+					t.Fatalf("decode input: %v", err)
+				}
+
+				var got bytes.Buffer
+				enc := json.NewEncoder(&got)
+				enc.SetEscapeHTML(false)
+				enc.SetIndent("", "  ")
+				if err := enc.Encode(offers); err != nil {
+					t.Fatal(err)
+				}
+
+				golden := strings.TrimSuffix(input, ".input.json") + ".golden.json"
+
+				if *update {
+					if err := os.WriteFile(golden, got.Bytes(), 0o644); err != nil {
+						t.Fatal(err)
+					}
+					return
+				}
+
+				want, err := os.ReadFile(golden)
+				if err != nil {
+					t.Fatalf("read golden (run with -update to create): %v", err)
+				}
+
+				if !bytes.Equal(bytes.TrimSpace(want), bytes.TrimSpace(got.Bytes())) {
+					t.Errorf("output differs from %s:\n%s", golden, diff(string(want), got.String()))
+				}
+			})
+		}
+	}
+}
+
+// diff — построчное сравнение, достаточное, чтобы найти расхождение
+// в отформатированном JSON.
+func diff(want, got string) string {
+	w := strings.Split(strings.TrimSpace(want), "\n")
+	g := strings.Split(strings.TrimSpace(got), "\n")
+
+	var out strings.Builder
+	shown := 0
+	for i := 0; i < len(w) || i < len(g); i++ {
+		var wl, gl string
+		if i < len(w) {
+			wl = w[i]
+		}
+		if i < len(g) {
+			gl = g[i]
+		}
+		if wl == gl {
+			continue
+		}
+		fmt.Fprintf(&out, "  line %d:\n    want: %s\n    got:  %s\n", i+1, wl, gl)
+		if shown++; shown == 5 {
+			out.WriteString("  ...\n")
+			break
+		}
+	}
+	return out.String()
+}
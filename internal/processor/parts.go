@@ -0,0 +1,247 @@
+package processor
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"dynamic-pricing-tool-ru/internal/storage"
+	"dynamic-pricing-tool-ru/internal/types"
+	"dynamic-pricing-tool-ru/internal/utils"
+)
+
+// DefaultSummaryCurrency — валюта сводки, если у клиента не задана своя.
+const DefaultSummaryCurrency = "RUB"
+
+// GroupByPart собирает плоский список офферов в строки BOM в порядке parts.
+// Строки без предложений тоже попадают в результат — с пустой сводкой.
+// Офферы внутри строки отсортированы по цене при запрошенном количестве
+// с учётом доверия к продавцу;
+// предложения по аналогам вынесены в Alternatives.
+func (p *Processor) GroupByPart(ctx context.Context, parts []types.PartData, offers []types.UnifiedOffer, currency string) []types.PartView {
+	if currency == "" {
+		currency = DefaultSummaryCurrency
+	}
+	pricing := p.pricingFor(ctx)
+
+	byRow := map[string][]types.UnifiedOffer{}
+	for _, o := range offers {
+		key := partKey(o.RequestedMPN, o.RequestedQty)
+		byRow[key] = append(byRow[key], o)
+	}
+
+	views := make([]types.PartView, 0, len(parts))
+
+	for _, part := range parts {
+		var rowOffers, alternatives []types.UnifiedOffer
+		for _, o := range byRow[partKey(part.PartNumber, part.Qty)] {
+			if o.Substitute != nil {
+				alternatives = append(alternatives, o)
+			} else {
+				rowOffers = append(rowOffers, o)
+			}
+		}
+
+		priced := priceOffers(rowOffers, part.Qty, currency, pricing)
+
+		view := types.PartView{
+			Row:          part.RowIndex,
+			RequestedMPN: part.PartNumber,
+			RequestedQty: part.Qty,
+			Summary:      summarize(priced, currency),
+			Offers:       offersOf(priced),
+		}
+		if len(alternatives) > 0 {
+			view.Alternatives = offersOf(priceOffers(alternatives, part.Qty, currency, pricing))
+		}
+		fillPartAttributes(&view, view.Offers)
+
+		views = append(views, view)
+	}
+
+	return views
+}
+
+// RankOffers сортирует плоский список так же, как GroupByPart: строки
+// BOM идут в порядке parts, внутри строки офферы — по цене с учётом
+// доверия к продавцу, предложения по аналогам — после них.
+func (p *Processor) RankOffers(ctx context.Context, parts []types.PartData, offers []types.UnifiedOffer, currency string) []types.UnifiedOffer {
+	if currency == "" {
+		currency = DefaultSummaryCurrency
+	}
+	pricing := p.pricingFor(ctx)
+
+	byRow := map[string][]types.UnifiedOffer{}
+	for _, o := range offers {
+		key := partKey(o.RequestedMPN, o.RequestedQty)
+		byRow[key] = append(byRow[key], o)
+	}
+
+	result := make([]types.UnifiedOffer, 0, len(offers))
+
+	for _, part := range uniqueParts(parts) {
+		var rowOffers, alternatives []types.UnifiedOffer
+		for _, o := range byRow[partKey(part.PartNumber, part.Qty)] {
+			if o.Substitute != nil {
+				alternatives = append(alternatives, o)
+			} else {
+				rowOffers = append(rowOffers, o)
+			}
+		}
+
+		result = append(result, offersOf(priceOffers(rowOffers, part.Qty, currency, pricing))...)
+		result = append(result, offersOf(priceOffers(alternatives, part.Qty, currency, pricing))...)
+	}
+
+	return result
+}
+
+func partKey(mpn string, qty int) string {
+	return strings.ToUpper(strings.TrimSpace(mpn)) + "|" + strconv.Itoa(qty)
+}
+
+type pricedOffer struct {
+	offer types.UnifiedOffer
+	// цена поставщика за штуку при запрошенном количестве в валюте
+	// сводки; -1 — цены нет или её не удалось пересчитать
+	price float64
+}
+
+// priceOffers считает цены за штуку и сортирует офферы по ним с поправкой
+// на доверие к продавцу; офферы без цены — в конце.
+func priceOffers(offers []types.UnifiedOffer, qty int, currency string, pricing Pricing) []pricedOffer {
+	priced := make([]pricedOffer, 0, len(offers))
+	for _, o := range offers {
+		priced = append(priced, pricedOffer{offer: o, price: unitPrice(o, qty, currency, pricing)})
+	}
+
+	sort.SliceStable(priced, func(i, j int) bool {
+		pi, pj := priced[i].price, priced[j].price
+		if (pi < 0) != (pj < 0) {
+			return pj < 0
+		}
+		return rankPrice(priced[i].offer, pi) < rankPrice(priced[j].offer, pj)
+	})
+
+	return priced
+}
+
+func offersOf(priced []pricedOffer) []types.UnifiedOffer {
+	offers := make([]types.UnifiedOffer, 0, len(priced))
+	for _, po := range priced {
+		offers = append(offers, po.offer)
+	}
+	return offers
+}
+
+func unitPrice(o types.UnifiedOffer, qty int, currency string, pricing Pricing) float64 {
+	pb, ok := PriceBreakForQty(o.PriceBreaks, qty)
+	if !ok || pb.Price <= 0 {
+		return -1
+	}
+	price, ok := pricing.convert(pb.Price, o.Currency, currency)
+	if !ok {
+		return -1
+	}
+	return price
+}
+
+func summarize(offers []pricedOffer, currency string) types.PartSummary {
+	summary := types.PartSummary{
+		Currency: currency,
+		Offers:   len(offers),
+	}
+
+	var prices []float64
+	sellers := map[string]bool{}
+	fastest := math.MaxInt
+
+	for _, po := range offers {
+		o := po.offer
+		summary.TotalStock += o.Stock
+		sellers[strings.ToLower(o.SellerName)] = true
+
+		if po.price >= 0 {
+			prices = append(prices, po.price)
+		}
+
+		if weeks, ok := deliveryWeeks(o.DeliveryTime); ok && weeks < fastest {
+			fastest = weeks
+			summary.FastestDelivery = o.DeliveryTime
+		}
+	}
+	summary.Sellers = len(sellers)
+
+	if len(prices) > 0 {
+		sort.Float64s(prices)
+		summary.MinPrice = utils.Round(prices[0], 2)
+		summary.MedianPrice = utils.Round(median(prices), 2)
+	}
+
+	return summary
+}
+
+func median(sorted []float64) float64 {
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// deliveryWeeks достаёт нижнюю границу срока из строки вида «2-3 недели»,
+// которую строит formatDeliveryTime.
+func deliveryWeeks(s string) (int, bool) {
+	digits := strings.FieldsFunc(s, func(r rune) bool { return !unicode.IsDigit(r) })
+	if len(digits) == 0 {
+		return 0, false
+	}
+	weeks, err := strconv.Atoi(digits[0])
+	return weeks, err == nil
+}
+
+// fillPartAttributes выбирает каноническое MPN — то, что чаще всего
+// встречается у поставщиков, — и берёт атрибуты детали из его офферов.
+func fillPartAttributes(view *types.PartView, offers []types.UnifiedOffer) {
+	counts := map[string]int{}
+	canonical := ""
+	for _, o := range offers {
+		key := storage.NormalizeMPN(o.MPN)
+		if key == "" {
+			continue
+		}
+		counts[key]++
+		if canonical == "" || counts[key] > counts[canonical] {
+			canonical = key
+		}
+	}
+	if canonical == "" {
+		return
+	}
+
+	manufacturers := map[string]int{}
+	for _, o := range offers {
+		if storage.NormalizeMPN(o.MPN) != canonical {
+			continue
+		}
+
+		fill(&view.MPN, o.MPN)
+		fill(&view.Description, o.Description)
+		fill(&view.Package, o.Package)
+		fill(&view.ImageURL, o.ImageURL)
+		if view.CategoryName == "" && o.CategoryName != "" {
+			view.CategoryID = o.CategoryID
+			view.CategoryName = o.CategoryName
+		}
+
+		if o.Manufacturer != "" {
+			manufacturers[o.Manufacturer]++
+			if view.Manufacturer == "" || manufacturers[o.Manufacturer] > manufacturers[view.Manufacturer] {
+				view.Manufacturer = o.Manufacturer
+			}
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package processor
+
+import (
+	"context"
+	"strings"
+
+	"dynamic-pricing-tool-ru/internal/types"
+	"dynamic-pricing-tool-ru/internal/utils"
+)
+
+// Pricing — общие параметры расчёта цен продажи.
+type Pricing struct {
+	VATRate float64
+	// VATIncluded — источники (source оффера), цены которых уже содержат НДС
+	VATIncluded map[string]bool
+	// Rates — курс валюты к рублю, например {"USD": 92.5}
+	Rates map[string]float64
+	// MarkupCoef — наценка для клиентов без своей; 0 — DefaultMarkupCoef
+	MarkupCoef float64
+}
+
+func (p Pricing) markup() float64 {
+	if p.MarkupCoef > 0 {
+		return p.MarkupCoef
+	}
+	return DefaultMarkupCoef
+}
+
+func (p Pricing) convert(amount float64, from, to string) (float64, bool) {
+	if from == to {
+		return amount, true
+	}
+
+	rate := func(cur string) float64 {
+		if cur == "RUB" {
+			return 1
+		}
+		return p.Rates[cur]
+	}
+
+	fromRate, toRate := rate(from), rate(to)
+	if fromRate <= 0 || toRate <= 0 {
+		return 0, false
+	}
+
+	return amount * fromRate / toRate, true
+}
+
+// reprice заново считает ступени оффера от цен поставщика. Расчёт ведётся
+// от цены без НДС, поэтому закупочные и целевые цены всегда нетто, а
+// TargetPriceSalesGross — та же цена продажи с НДС.
+func (p Pricing) reprice(o types.UnifiedOffer, markupCoef float64) types.UnifiedOffer {
+	o.PriceIncludesVAT = p.VATIncluded[o.Source]
+	vat := 1 + p.VATRate/100
+
+	pbs := make([]types.PriceBreak, 0, len(o.PriceBreaks))
+	for _, pb := range o.PriceBreaks {
+		net := pb.Price
+		if o.PriceIncludesVAT {
+			net = pb.Price / vat
+		}
+
+		pbs = append(pbs, types.PriceBreak{
+			Quantity: pb.Quantity,
+			Price:    net,
+		})
+	}
+
+	priceBreaks := buildPriceBreaksWithMarkup(pbs, o.Currency, markupCoef)
+	for i := range priceBreaks {
+		pb := &priceBreaks[i]
+		// в Price остаётся цена поставщика как есть
+		pb.Price = o.PriceBreaks[i].Price
+		pb.TargetPriceSalesNet = pb.TargetPriceSales
+		pb.TargetPriceSalesGross = utils.Round(pb.TargetPriceSales*vat, 2)
+	}
+	o.PriceBreaks = priceBreaks
+
+	return o
+}
+
+func (p Pricing) Apply(offers []types.UnifiedOffer) []types.UnifiedOffer {
+	for i := range offers {
+		offers[i] = p.reprice(offers[i], p.markup())
+	}
+	return offers
+}
+
+// SetPricing атомарно заменяет параметры расчёта: запросы, уже начавшие
+// пересчёт, дорабатывают со старыми.
+func (p *Processor) SetPricing(pricing Pricing) {
+	p.pricing.Store(&pricing)
+}
+
+func (p *Processor) Pricing() Pricing {
+	return *p.pricing.Load()
+}
+
+type pricingKey struct{}
+
+// WithPricing фиксирует параметры расчёта на весь запрос: перезагрузка
+// конфигурации посреди обработки BOM не должна дать строки, посчитанные
+// по разным курсам и наценкам.
+func (p *Processor) WithPricing(ctx context.Context) context.Context {
+	return context.WithValue(ctx, pricingKey{}, p.pricing.Load())
+}
+
+// pricingFor возвращает параметры, зафиксированные WithPricing, а без
+// них — действующие.
+func (p *Processor) pricingFor(ctx context.Context) Pricing {
+	if pricing, ok := ctx.Value(pricingKey{}).(*Pricing); ok {
+		return *pricing
+	}
+	return p.Pricing()
+}
+
+// ApplyCustomerProfile пересчитывает цены продажи по условиям клиента:
+// наценка, валюта, НДС и исключённые поставщики. Без профиля цены
+// пересчитываются по стандартной наценке.
+func (p *Processor) ApplyCustomerProfile(ctx context.Context, offers []types.UnifiedOffer, profile *types.CustomerProfile) []types.UnifiedOffer {
+	if profile == nil {
+		profile = &types.CustomerProfile{}
+	}
+
+	pricing := p.pricingFor(ctx)
+
+	markupCoef := profile.MarkupCoef
+	if markupCoef <= 0 {
+		markupCoef = pricing.markup()
+	}
+
+	result := make([]types.UnifiedOffer, 0, len(offers))
+
+	for _, o := range offers {
+		if isExcludedSupplier(profile.ExcludedSuppliers, o) {
+			continue
+		}
+
+		o = pricing.reprice(o, markupCoef)
+
+		if profile.Currency != "" && profile.Currency != o.Currency {
+			if price, ok := pricing.convert(o.Price, o.Currency, profile.Currency); ok {
+				for i := range o.PriceBreaks {
+					pb := &o.PriceBreaks[i]
+					for _, v := range []*float64{
+						&pb.Price,
+						&pb.CostWithDelivery,
+						&pb.TargetPricePurchasing,
+						&pb.TargetPriceSales,
+						&pb.TargetPriceSalesNet,
+						&pb.TargetPriceSalesGross,
+					} {
+						converted, _ := pricing.convert(*v, o.Currency, profile.Currency)
+						*v = utils.Round(converted, 2)
+					}
+					pb.Currency = profile.Currency
+				}
+
+				o.Price = utils.Round(price, 2)
+				o.Currency = profile.Currency
+			}
+		}
+
+		if profile.IncludeVAT {
+			for i := range o.PriceBreaks {
+				o.PriceBreaks[i].TargetPriceSales = o.PriceBreaks[i].TargetPriceSalesGross
+			}
+		}
+
+		result = append(result, o)
+	}
+
+	return result
+}
+
+func isExcludedSupplier(excluded []string, o types.UnifiedOffer) bool {
+	for _, name := range excluded {
+		if strings.EqualFold(name, o.Source) || strings.EqualFold(name, o.SellerName) {
+			return true
+		}
+	}
+	return false
+}
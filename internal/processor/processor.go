@@ -2,50 +2,98 @@ package processor
 
 import (
 	"context"
-	"fmt"
-	"strconv"
-	"strings"
 	"sync"
+	"sync/atomic"
 
 	"dynamic-pricing-tool-ru/internal/api"
+	"dynamic-pricing-tool-ru/internal/metrics"
+	"dynamic-pricing-tool-ru/internal/storage"
+	"dynamic-pricing-tool-ru/internal/tracing"
 	"dynamic-pricing-tool-ru/internal/types"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type Processor struct {
 	combinedClient *api.CombinedAPIClient
 	chunkSize      int
 	workerPoolSize int
+	history        *storage.PriceHistory
+	crossRefs      *storage.CrossRefs
+	// pricing подменяется целиком при перезагрузке конфигурации
+	pricing     atomic.Pointer[Pricing]
+	sellerRules atomic.Pointer[SellerRuleSet]
+	limits      Limits
 }
 
 func NewProcessorWithClients(getchipsClient *api.GetchipsClient, efindClient *api.EfindClient, promelec *api.PromelecClient, chunkSize int) *Processor {
 	combinedClient := api.NewCombinedAPIClient(getchipsClient, efindClient, promelec)
-	return &Processor{
+	p := &Processor{
 		combinedClient: combinedClient,
 		chunkSize:      chunkSize,
 		workerPoolSize: 20,
+		limits:         DefaultLimits(),
 	}
+	p.pricing.Store(&Pricing{})
+	p.sellerRules.Store(NewSellerRuleSet(nil))
+	return p
+}
+
+func (p *Processor) CombinedClient() *api.CombinedAPIClient {
+	return p.combinedClient
 }
 
+// SetHistory включает сохранение цен всех найденных офферов.
+func (p *Processor) SetHistory(history *storage.PriceHistory) {
+	p.history = history
+}
+
+// ProcessRequest проверяет запрос и обрабатывает корректные строки,
+// пропуская строки с ошибками.
 func (p *Processor) ProcessRequest(ctx context.Context, req *types.Request) ([]types.UnifiedOffer, error) {
-	parts, err := p.extractPartData(req)
+	validation, err := p.ValidateRequest(req)
 	if err != nil {
 		return nil, err
 	}
 
+	return p.ProcessParts(ctx, validation.Parts)
+}
+
+// ProcessParts опрашивает поставщиков по уже проверенным строкам BOM.
+func (p *Processor) ProcessParts(ctx context.Context, parts []types.PartData) (offers []types.UnifiedOffer, err error) {
+	ctx, span := tracing.Start(ctx, "processor.process")
+	defer func() { tracing.End(span, err) }()
+
+	span.SetAttributes(attribute.Int("bom.rows", len(parts)))
+
+	// повторяющиеся строки получают одни и те же офферы по partKey
+	parts = uniqueParts(parts)
+
+	parent := ctx
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
 	jobs := make(chan types.PartData, len(parts))
 	resultsChan := make(chan types.UnifiedOffer, len(parts))
 
+	// строки, не взятые воркерами (например, после отмены), снимаем с гейджа в конце
+	pending := int64(len(parts))
+	metrics.ProcessorQueueDepth.Add(float64(pending))
+	defer func() {
+		metrics.ProcessorQueueDepth.Sub(float64(atomic.LoadInt64(&pending)))
+	}()
+
 	var wg sync.WaitGroup
 
 	for i := 0; i < p.workerPoolSize; i++ {
 		wg.Add(1)
-		go p.worker(ctx, jobs, resultsChan, &wg)
+		go p.worker(ctx, jobs, resultsChan, &wg, &pending)
 	}
 
 	go func() {
+		// jobs закрывается и при отмене, иначе воркеры остались бы ждать
+		// в range и ProcessParts не вернулся бы
+		defer close(jobs)
 		for _, part := range parts {
 			select {
 			case <-ctx.Done():
@@ -53,7 +101,6 @@ func (p *Processor) ProcessRequest(ctx context.Context, req *types.Request) ([]t
 			case jobs <- part:
 			}
 		}
-		close(jobs)
 	}()
 
 	go func() {
@@ -66,10 +113,52 @@ func (p *Processor) ProcessRequest(ctx context.Context, req *types.Request) ([]t
 		allOffers = append(allOffers, o)
 	}
 
+	// запрос отменён (клиент ушёл или сервер останавливается) — часть строк
+	// не обработана, отдавать неполный результат как готовый нельзя
+	if err := parent.Err(); err != nil {
+		return allOffers, err
+	}
+
 	return allOffers, nil
 }
 
-func (p *Processor) worker(ctx context.Context, jobs <-chan types.PartData, results chan<- types.UnifiedOffer, wg *sync.WaitGroup) {
+// uniqueParts оставляет первую из строк с одинаковыми MPN и количеством.
+func uniqueParts(parts []types.PartData) []types.PartData {
+	seen := make(map[string]bool, len(parts))
+	unique := make([]types.PartData, 0, len(parts))
+	for _, part := range parts {
+		key := partKey(part.PartNumber, part.Qty)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, part)
+	}
+	return unique
+}
+
+// SearchPart опрашивает всех поставщиков по одной позиции так же, как
+// строку BOM: правила продавцов, расчёт цен и запись в историю. Ошибка
+// означает сбой поставщика — офферы тогда неполные, но всё равно
+// возвращаются.
+func (p *Processor) SearchPart(ctx context.Context, partNumber string, qty int) ([]types.UnifiedOffer, error) {
+	apiResult := p.combinedClient.SearchAllAPIs(ctx, partNumber, qty)
+
+	offers := p.pricingFor(ctx).Apply(p.SellerRules().Apply(CollectOffers(apiResult, partNumber, qty)))
+
+	if p.history != nil {
+		p.history.Record(offers)
+	}
+
+	return offers, api.SearchError(apiResult)
+}
+
+func (p *Processor) searchOffers(ctx context.Context, partNumber string, qty int) []types.UnifiedOffer {
+	offers, _ := p.SearchPart(ctx, partNumber, qty)
+	return offers
+}
+
+func (p *Processor) worker(ctx context.Context, jobs <-chan types.PartData, results chan<- types.UnifiedOffer, wg *sync.WaitGroup, pending *int64) {
 	defer wg.Done()
 
 	for part := range jobs {
@@ -77,92 +166,50 @@ func (p *Processor) worker(ctx context.Context, jobs <-chan types.PartData, resu
 		case <-ctx.Done():
 			return
 		default:
-			qty := p.parseQuantity(part.Quantity)
+			atomic.AddInt64(pending, -1)
+			metrics.ProcessorQueueDepth.Dec()
+			metrics.ProcessorActiveWorkers.Inc()
 
-			apiResult := p.combinedClient.SearchAllAPIs(ctx, part.PartNumber, qty)
+			qty := part.Qty
 
-			offers := []types.UnifiedOffer{}
+			rowCtx, span := tracing.Start(ctx, "processor.row",
+				attribute.Int("bom.row_index", part.RowIndex),
+				attribute.String("bom.part_number", part.PartNumber),
+				attribute.Int("bom.quantity", qty),
+			)
 
-			offers = append(offers, FormatGetchipsData(apiResult.GetchipsData, part.PartNumber, qty)...)
-			//offers = append(offers, FormatEfindData(apiResult.EfindData, part.PartNumber, qty)...)
-			offers = append(offers, FormatPromelecData(apiResult.PromelecData, part.PartNumber, qty)...)
+			offers := p.searchOffers(rowCtx, part.PartNumber, qty)
 
 			for _, o := range offers {
 				results <- o
 			}
-		}
-	}
-}
-
-func (p *Processor) parseQuantity(quantityStr string) int {
-	if quantityStr == "" {
-		return 1
-	}
-
-	quantityStr = strings.TrimSpace(quantityStr)
-
-	qty, err := strconv.Atoi(quantityStr)
-	if err != nil {
-		return 1
-	}
-
-	if qty <= 0 {
-		return 1
-	}
 
-	return qty
-}
-
-func (p *Processor) extractPartData(req *types.Request) ([]types.PartData, error) {
-	if len(req.Data) < 2 {
-		return nil, fmt.Errorf("insufficient data")
-	}
+			span.SetAttributes(attribute.Int("bom.offers", len(offers)))
+			span.End()
 
-	var parts []types.PartData
-
-	partNumberIndex := -1
-	quantityIndex := -1
-
-	for key, value := range req.Mapping {
-		switch value {
-		case "partNumber":
-			if idx, err := strconv.Atoi(key); err == nil {
-				partNumberIndex = idx
-			}
-		case "quantity":
-			if idx, err := strconv.Atoi(key); err == nil {
-				quantityIndex = idx
-			}
+			metrics.ProcessorActiveWorkers.Dec()
+			metrics.BOMRowsProcessed.Inc()
 		}
 	}
+}
 
-	if partNumberIndex == -1 {
-		return nil, fmt.Errorf("partNumber mapping not found")
-	}
+// CollectOffers приводит ответы всех поставщиков к единому списку офферов.
+func CollectOffers(apiResult types.APIResponse, requestedMPN string, qty int) []types.UnifiedOffer {
+	offers := []types.UnifiedOffer{}
 
-	for i := 1; i < len(req.Data); i++ {
-		row := req.Data[i]
+	getchips := FormatGetchipsData(apiResult.GetchipsData, requestedMPN, qty)
+	metrics.SupplierOffers.WithLabelValues("getchips").Add(float64(len(getchips)))
+	offers = append(offers, getchips...)
 
-		if len(row) <= partNumberIndex {
-			continue
-		}
-
-		partNumber := strings.TrimSpace(row[partNumberIndex])
-		if partNumber == "" {
-			continue
-		}
+	efind := FormatEfindData(apiResult.EfindData, requestedMPN, qty)
+	metrics.SupplierOffers.WithLabelValues("efind").Add(float64(len(efind)))
+	offers = append(offers, efind...)
 
-		quantity := ""
-		if quantityIndex != -1 && len(row) > quantityIndex {
-			quantity = strings.TrimSpace(row[quantityIndex])
-		}
+	promelec := FormatPromelecData(apiResult.PromelecData, requestedMPN, qty)
+	metrics.SupplierOffers.WithLabelValues("promelec").Add(float64(len(promelec)))
+	offers = append(offers, promelec...)
 
-		parts = append(parts, types.PartData{
-			PartNumber: partNumber,
-			Quantity:   quantity,
-			RowIndex:   i,
-		})
-	}
+	Enrich(offers)
 
-	return parts, nil
+	return offers
 }
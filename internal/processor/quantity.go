@@ -0,0 +1,257 @@
+package processor
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	PackReel = "reel"
+	PackTray = "tray"
+)
+
+// максимальное количество в одной строке; всё, что больше, почти наверняка
+// ошибка в выгрузке (например, склеенные ячейки)
+const maxQuantity = 100_000_000
+
+// DefaultPackSizes — количество штук в упаковке, если в конфиге не задано.
+func DefaultPackSizes() map[string]int {
+	return map[string]int{
+		PackReel: 3000,
+		PackTray: 100,
+	}
+}
+
+var quantityPattern = regexp.MustCompile(`^([0-9][0-9 \x{00a0}\x{202f}'.,]*(?:[eE][+]?[0-9]+)?)\s*([\p{L}.]*)$`)
+
+// суффиксы единиц измерения: штуки, тысячи и упаковки
+var (
+	pieceSuffixes    = []string{"шт", "шт.", "штук", "штуки", "pcs", "pcs.", "pc", "pc.", "ea", "ea."}
+	thousandSuffixes = []string{"к", "k", "тыс", "тыс."}
+	packSuffixes     = map[string][]string{
+		PackReel: {"reel", "reels", "катушка", "катушки", "катушек", "кат", "кат.", "рил", "рила", "рилов"},
+		PackTray: {"tray", "trays", "трей", "трея", "треев", "лоток", "лотка", "лотков"},
+	}
+)
+
+// parseQuantity разбирает количество в том виде, в каком его пишут в BOM:
+// "1 000", "1,000", "2.5к", "10 шт", "1e3", "5 reels".
+// Если значение получено не из простого целого числа, возвращается
+// пояснение (inferred), которое попадает в предупреждение по строке.
+func parseQuantity(quantityStr string, packSizes map[string]int) (qty int, inferred string, err error) {
+	s := strings.ToLower(strings.TrimSpace(quantityStr))
+
+	if plain, err := strconv.Atoi(s); err == nil {
+		if plain <= 0 {
+			return 0, "", fmt.Errorf("quantity must be positive")
+		}
+		if plain > maxQuantity {
+			return 0, "", fmt.Errorf("quantity is too large")
+		}
+		return plain, "", nil
+	}
+
+	m := quantityPattern.FindStringSubmatch(s)
+	if m == nil {
+		return 0, "", fmt.Errorf("quantity is not a number")
+	}
+
+	number, suffix := m[1], m[2]
+
+	multiplier := 1
+	var notes []string
+
+	switch {
+	case suffix == "" || contains(pieceSuffixes, suffix):
+	case contains(thousandSuffixes, suffix):
+		multiplier = 1000
+		notes = append(notes, "thousands suffix")
+	default:
+		pack := ""
+		for name, suffixes := range packSuffixes {
+			if contains(suffixes, suffix) {
+				pack = name
+				break
+			}
+		}
+		if pack == "" {
+			return 0, "", fmt.Errorf("unknown quantity unit %q", suffix)
+		}
+		size := packSizes[pack]
+		if size <= 0 {
+			return 0, "", fmt.Errorf("%s size is not configured", pack)
+		}
+		multiplier = size
+		notes = append(notes, fmt.Sprintf("%d pcs per %s", size, pack))
+	}
+
+	value, separators, err := parseNumber(number, multiplier == 1)
+	if err != nil {
+		return 0, "", err
+	}
+	if separators != "" {
+		notes = append(notes, separators)
+	}
+
+	total := value * float64(multiplier)
+	rounded := math.Round(total)
+
+	if math.Abs(total-rounded) > 1e-9 {
+		return 0, "", fmt.Errorf("quantity %s is not a whole number", strconv.FormatFloat(total, 'f', -1, 64))
+	}
+	if rounded <= 0 {
+		return 0, "", fmt.Errorf("quantity must be positive")
+	}
+	if rounded > maxQuantity {
+		return 0, "", fmt.Errorf("quantity is too large")
+	}
+
+	qty = int(rounded)
+
+	// "шт" не делает значение неоднозначным
+	if len(notes) == 0 {
+		return qty, "", nil
+	}
+
+	return qty, fmt.Sprintf("%q interpreted as %d (%s)", strings.TrimSpace(quantityStr), qty, strings.Join(notes, ", ")), nil
+}
+
+// parseNumber разбирает число с разделителями разрядов и десятичной частью.
+// Одиночная запятая или точка перед ровно тремя цифрами в штуках считается
+// разделителем разрядов ("1,000" = 1000), в остальных случаях — десятичной
+// ("2,5к"). Второе значение — пояснение, если трактовка неоднозначна.
+func parseNumber(s string, pieces bool) (float64, string, error) {
+	s = strings.TrimSpace(strings.NewReplacer("\u00a0", " ", "\u202f", " ").Replace(s))
+
+	if strings.ContainsAny(s, " '") {
+		return parseGrouped(s, pieces)
+	}
+
+	note := ""
+
+	if strings.ContainsAny(s, "e") {
+		note = "scientific notation"
+	} else {
+		commas := strings.Count(s, ",")
+		dots := strings.Count(s, ".")
+
+		switch {
+		case commas > 0 && dots > 0:
+			// последний из разделителей — десятичный
+			if strings.LastIndex(s, ",") > strings.LastIndex(s, ".") {
+				s = strings.ReplaceAll(s, ".", "")
+				s = strings.Replace(s, ",", ".", 1)
+			} else {
+				s = strings.ReplaceAll(s, ",", "")
+			}
+			if strings.Count(s, ".") > 1 {
+				return 0, "", fmt.Errorf("quantity is not a number")
+			}
+			note = "mixed separators"
+		case commas+dots > 1:
+			sep := ","
+			if dots > 0 {
+				sep = "."
+			}
+			if !groupedByThousands(s, sep) {
+				return 0, "", fmt.Errorf("quantity is not a number")
+			}
+			s = strings.ReplaceAll(s, sep, "")
+			note = "thousands separator"
+		case commas+dots == 1:
+			sep := ","
+			if dots > 0 {
+				sep = "."
+			}
+			idx := strings.Index(s, sep)
+			if pieces && len(s)-idx-1 == 3 {
+				s = strings.Replace(s, sep, "", 1)
+				note = "thousands separator"
+			} else {
+				s = strings.Replace(s, sep, ".", 1)
+				if pieces {
+					note = "decimal separator"
+				}
+			}
+		}
+	}
+
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsInf(value, 0) || math.IsNaN(value) {
+		return 0, "", fmt.Errorf("quantity is not a number")
+	}
+
+	return value, note, nil
+}
+
+// parseGrouped разбирает число, где разряды отделены пробелами или
+// апострофами: "1 000 000", "1'000", "1 000,5к". Группы должны быть по три
+// цифры, иначе "1 5" превратилось бы в 15; запятая или точка после них —
+// десятичная.
+func parseGrouped(s string, pieces bool) (float64, string, error) {
+	sep := " "
+	if strings.Contains(s, "'") {
+		if strings.Contains(s, " ") {
+			return 0, "", fmt.Errorf("quantity mixes spaces and apostrophes as separators")
+		}
+		sep = "'"
+	}
+	if strings.Contains(s, "e") {
+		return 0, "", fmt.Errorf("quantity is not a number")
+	}
+
+	integer, fraction, decimal := strings.Cut(strings.NewReplacer(",", ".").Replace(s), ".")
+	if decimal && (fraction == "" || strings.ContainsAny(fraction, ". '")) {
+		return 0, "", fmt.Errorf("quantity is not a number")
+	}
+	// "1 000,000" в штуках — то ли ещё один разряд, то ли дробная часть
+	if decimal && pieces && len(fraction) == 3 {
+		return 0, "", fmt.Errorf("quantity has ambiguous separators")
+	}
+	if !groupedByThousands(integer, sep) {
+		return 0, "", fmt.Errorf("quantity has digit groups that are not thousands")
+	}
+
+	s = strings.ReplaceAll(integer, sep, "")
+	note := "thousands separator"
+	if decimal {
+		s += "." + fraction
+		if pieces {
+			note += ", decimal separator"
+		}
+	}
+
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsInf(value, 0) || math.IsNaN(value) {
+		return 0, "", fmt.Errorf("quantity is not a number")
+	}
+
+	return value, note, nil
+}
+
+// groupedByThousands проверяет, что после каждого разделителя идут ровно
+// три цифры: "1.000.000" — разряды, "1.5.2" — мусор.
+func groupedByThousands(s, sep string) bool {
+	groups := strings.Split(s, sep)
+	if len(groups[0]) == 0 || len(groups[0]) > 3 {
+		return false
+	}
+	for _, g := range groups[1:] {
+		if len(g) != 3 {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,165 @@
+package processor
+
+import (
+	"strings"
+	"testing"
+
+	"dynamic-pricing-tool-ru/internal/types"
+)
+
+func TestParseQuantity(t *testing.T) {
+	packs := map[string]int{PackReel: 3000, PackTray: 100}
+
+	tests := []struct {
+		in   string
+		want int
+		// note — фрагмент пояснения; пустая строка — пояснения быть не должно
+		note    string
+		wantErr string
+	}{
+		{in: "25", want: 25},
+		{in: " 25 ", want: 25},
+		{in: "10 шт", want: 10},
+		{in: "10pcs.", want: 10},
+
+		// разделители разрядов
+		{in: "1 000", want: 1000, note: "thousands separator"},
+		{in: "1\u00a0000\u00a0000", want: 1_000_000, note: "thousands separator"},
+		{in: "1\u202f000", want: 1000, note: "thousands separator"},
+		{in: "1'000", want: 1000, note: "thousands separator"},
+		{in: "12 500 шт", want: 12500, note: "thousands separator"},
+		{in: "1 000,00", want: 1000, note: "decimal separator"},
+		{in: "1,000", want: 1000, note: "thousands separator"},
+		{in: "1.000.000", want: 1_000_000, note: "thousands separator"},
+		{in: "1.000,00", want: 1000, note: "mixed separators"},
+		{in: "1,5", wantErr: "not a whole number"},
+		{in: "1 5", wantErr: "not thousands"},
+		{in: "12 34", wantErr: "not thousands"},
+		{in: "1 0000", wantErr: "not thousands"},
+		{in: "1  000", wantErr: "not thousands"},
+		{in: "1234 567", wantErr: "not thousands"},
+		{in: "1'000 000", wantErr: "mixes spaces and apostrophes"},
+		{in: "1 000,000", wantErr: "ambiguous"},
+		{in: "1.5.2", wantErr: "not a number"},
+
+		// суффиксы и упаковки
+		{in: "2.5к", want: 2500, note: "thousands suffix"},
+		{in: "2,5k", want: 2500, note: "thousands suffix"},
+		{in: "3 тыс.", want: 3000, note: "thousands suffix"},
+		{in: "1 500k", want: 1_500_000, note: "thousands separator"},
+		{in: "1e3", want: 1000, note: "scientific notation"},
+		{in: "5 reels", want: 15000, note: "3000 pcs per reel"},
+		{in: "2 катушки", want: 6000, note: "3000 pcs per reel"},
+		{in: "3 лотка", want: 300, note: "100 pcs per tray"},
+		{in: "0.5 reel", want: 1500, note: "3000 pcs per reel"},
+		{in: "10 boxes", wantErr: "unknown quantity unit"},
+
+		// ошибки
+		{in: "0", wantErr: "must be positive"},
+		{in: "-5", wantErr: "must be positive"},
+		{in: "abc", wantErr: "not a number"},
+		{in: "200000000", wantErr: "too large"},
+		{in: "200 000к", wantErr: "too large"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, inferred, err := parseQuantity(tt.in, packs)
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q (got %d)", err, tt.wantErr, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("qty = %d, want %d", got, tt.want)
+			}
+			if tt.note == "" && inferred != "" {
+				t.Errorf("unexpected note %q", inferred)
+			}
+			if !strings.Contains(inferred, tt.note) {
+				t.Errorf("note = %q, want it to mention %q", inferred, tt.note)
+			}
+		})
+	}
+
+	t.Run("pack size not configured", func(t *testing.T) {
+		if _, _, err := parseQuantity("2 reels", nil); err == nil || !strings.Contains(err.Error(), "not configured") {
+			t.Fatalf("err = %v", err)
+		}
+	})
+}
+
+func TestValidateRequest(t *testing.T) {
+	p := &Processor{limits: DefaultLimits()}
+
+	req := &types.Request{
+		Mapping: map[string]string{"0": "partNumber", "1": "quantity"},
+		Data: [][]string{
+			{"MPN", "Qty"},
+			{"NE555DR", "25"},
+			{"LM317DCYR", "1 000"},
+			{"BSS84AKW", "12 34"},
+			{"", ""},
+			{"", "5"},
+			{"TL431", ""},
+			{"NE555DR", "25"},
+			{"BC847", "2 reels"},
+			{strings.Repeat("X", 65), "1"},
+		},
+	}
+
+	result, err := p.ValidateRequest(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type problem struct {
+		row      int
+		code     string
+		severity string
+	}
+	var got []problem
+	for _, pr := range result.Problems {
+		got = append(got, problem{pr.Row, pr.Code, pr.Severity})
+	}
+	want := []problem{
+		{2, ProblemQuantityInferred, SeverityWarning},
+		{3, ProblemInvalidQuantity, SeverityError},
+		{5, ProblemEmptyPartNumber, SeverityError},
+		{6, ProblemQuantityDefaulted, SeverityWarning},
+		{7, ProblemDuplicateRow, SeverityWarning},
+		{8, ProblemQuantityInferred, SeverityWarning},
+		{9, ProblemPartNumberTooLong, SeverityError},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("problems = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("problem %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	qty := map[int]int{}
+	for _, part := range result.Parts {
+		qty[part.RowIndex] = part.Qty
+	}
+	wantQty := map[int]int{1: 25, 2: 1000, 6: 1, 7: 25, 8: 6000}
+	if len(qty) != len(wantQty) {
+		t.Fatalf("parts = %+v, want rows %v", result.Parts, wantQty)
+	}
+	for row, q := range wantQty {
+		if qty[row] != q {
+			t.Errorf("row %d qty = %d, want %d", row, qty[row], q)
+		}
+	}
+
+	if !result.HasErrors() {
+		t.Error("HasErrors() = false")
+	}
+}
@@ -0,0 +1,241 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"dynamic-pricing-tool-ru/internal/types"
+	"dynamic-pricing-tool-ru/internal/utils"
+)
+
+const (
+	QuoteItemUnchanged = "unchanged"
+	QuoteItemChanged   = "changed"
+	QuoteItemNotFound  = "not_found"
+	// QuoteItemUnpriced — продавец найден, но цены на это количество нет
+	QuoteItemUnpriced = "unpriced"
+)
+
+// PriceBreakForQty выбирает ступень с наибольшим порогом, не превышающим qty.
+// Если qty меньше минимальной ступени, берётся первая.
+func PriceBreakForQty(pbs []types.UnifiedPriceBreak, qty int) (types.UnifiedPriceBreak, bool) {
+	if len(pbs) == 0 {
+		return types.UnifiedPriceBreak{}, false
+	}
+
+	best := pbs[0]
+	for _, pb := range pbs {
+		if pb.Quantity <= qty && pb.Quantity >= best.Quantity {
+			best = pb
+		}
+	}
+
+	return best, true
+}
+
+// quoteParts — поиски для позиций нового КП: одинаковые позиции ищутся
+// один раз.
+func quoteParts(requested []types.QuoteRequestItem) []types.PartData {
+	parts := make([]types.PartData, 0, len(requested))
+	for i, r := range requested {
+		parts = append(parts, types.PartData{PartNumber: r.MPN, Qty: r.Quantity, RowIndex: i + 1})
+	}
+	return uniqueParts(parts)
+}
+
+// QuoteSearches — сколько поисков сделает BuildQuoteItems. По этому числу
+// списывается квота.
+func QuoteSearches(requested []types.QuoteRequestItem) int {
+	return len(quoteParts(requested))
+}
+
+// ErrQuoteOfferNotFound — по позиции КП не нашлось подходящего предложения.
+var ErrQuoteOfferNotFound = errors.New("no offer found")
+
+// BuildQuoteItems заново опрашивает поставщиков по позициям КП и
+// замораживает цены выбранных офферов. Цены считаются так же, как в
+// /process: от цены поставщика в его валюте, с пересчётом по профилю клиента.
+func (p *Processor) BuildQuoteItems(ctx context.Context, requested []types.QuoteRequestItem, profile *types.CustomerProfile) ([]types.QuoteItem, error) {
+	offers, err := p.ProcessParts(ctx, quoteParts(requested))
+	if err != nil {
+		return nil, err
+	}
+	offers = p.ApplyCustomerProfile(ctx, offers, profile)
+
+	byRow := map[string][]types.UnifiedOffer{}
+	for _, o := range offers {
+		key := partKey(o.RequestedMPN, o.RequestedQty)
+		byRow[key] = append(byRow[key], o)
+	}
+
+	pricing := p.pricingFor(ctx)
+	items := make([]types.QuoteItem, 0, len(requested))
+	var missing []string
+
+	for _, r := range requested {
+		o, ok := selectQuoteOffer(byRow[partKey(r.MPN, r.Quantity)], r, pricing)
+		if !ok {
+			missing = append(missing, r.MPN)
+			continue
+		}
+
+		pb, _ := PriceBreakForQty(o.PriceBreaks, r.Quantity)
+		items = append(items, types.QuoteItem{
+			Offer:     o,
+			Quantity:  r.Quantity,
+			Currency:  o.Currency,
+			UnitPrice: pb.TargetPriceSales,
+			Total:     utils.Round(pb.TargetPriceSales*float64(r.Quantity), 2),
+		})
+	}
+
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrQuoteOfferNotFound, strings.Join(missing, ", "))
+	}
+
+	return items, nil
+}
+
+// selectQuoteOffer выбирает оффер по поставщику и продавцу из запроса,
+// а без них — самый дешёвый с учётом доверия, предпочитая те, где
+// запрошенное количество есть на складе. Офферы без цены не выбираются.
+func selectQuoteOffer(offers []types.UnifiedOffer, r types.QuoteRequestItem, pricing Pricing) (types.UnifiedOffer, bool) {
+	var (
+		best      types.UnifiedOffer
+		bestRank  float64
+		bestStock bool
+		found     bool
+	)
+
+	for _, o := range offers {
+		if r.Source != "" && !strings.EqualFold(o.Source, r.Source) {
+			continue
+		}
+		if r.SellerName != "" && !strings.EqualFold(o.SellerName, r.SellerName) {
+			continue
+		}
+
+		pb, ok := PriceBreakForQty(o.PriceBreaks, r.Quantity)
+		if !ok || pb.TargetPriceSales <= 0 {
+			continue
+		}
+		price, ok := pricing.convert(pb.TargetPriceSales, o.Currency, DefaultSummaryCurrency)
+		if !ok {
+			continue
+		}
+
+		rank := rankPrice(o, price)
+		inStock := o.Stock >= r.Quantity
+
+		if !found || (inStock && !bestStock) || (inStock == bestStock && rank < bestRank) {
+			best, bestRank, bestStock, found = o, rank, inStock, true
+		}
+	}
+
+	return best, found
+}
+
+func QuoteTotals(items []types.QuoteItem) map[string]float64 {
+	totals := map[string]float64{}
+	for _, item := range items {
+		totals[item.Currency] = utils.Round(totals[item.Currency]+item.Total, 2)
+	}
+	return totals
+}
+
+// repriceSearch — запрос, которым позиция КП ищется заново. Поиск зависит
+// от количества, поэтому ключ — та же пара, что и у строк BOM.
+func repriceSearch(item types.QuoteItem) (mpn, key string) {
+	mpn = item.Offer.RequestedMPN
+	if mpn == "" {
+		mpn = item.Offer.MPN
+	}
+	return mpn, partKey(mpn, item.Quantity)
+}
+
+// RepriceSearches — сколько поисков сделает RepriceQuote: одинаковые
+// позиции ищутся один раз. По этому числу списывается квота.
+func RepriceSearches(quote types.Quote) int {
+	seen := make(map[string]bool, len(quote.Items))
+	for _, item := range quote.Items {
+		_, key := repriceSearch(item)
+		seen[key] = true
+	}
+	return len(seen)
+}
+
+// RepriceQuote сравнивает замороженные цены КП с текущими данными поставщиков.
+func (p *Processor) RepriceQuote(ctx context.Context, quote types.Quote, profile *types.CustomerProfile) []types.QuoteItemDiff {
+	current := map[string][]types.UnifiedOffer{}
+	diffs := make([]types.QuoteItemDiff, 0, len(quote.Items))
+
+	for _, item := range quote.Items {
+		o := item.Offer
+
+		mpn, searchKey := repriceSearch(item)
+		offers, ok := current[searchKey]
+		if !ok {
+			offers = p.ApplyCustomerProfile(ctx, p.searchOffers(ctx, mpn, item.Quantity), profile)
+			current[searchKey] = offers
+		}
+
+		diff := types.QuoteItemDiff{
+			MPN:             o.MPN,
+			SellerName:      o.SellerName,
+			Source:          o.Source,
+			Quantity:        item.Quantity,
+			Currency:        item.Currency,
+			QuotedUnitPrice: item.UnitPrice,
+			QuotedStock:     o.Stock,
+			Status:          QuoteItemNotFound,
+		}
+
+		if match, found := findMatchingOffer(offers, o); found {
+			diff.CurrentStock = match.Stock
+
+			pb, ok := PriceBreakForQty(match.PriceBreaks, item.Quantity)
+			if !ok || pb.TargetPriceSales <= 0 {
+				diff.Status = QuoteItemUnpriced
+			} else {
+				diff.CurrentUnitPrice = pb.TargetPriceSales
+				diff.Difference = utils.Round(pb.TargetPriceSales-item.UnitPrice, 2)
+				if item.UnitPrice > 0 {
+					diff.DifferencePercent = utils.Round(diff.Difference/item.UnitPrice*100, 2)
+				}
+
+				diff.Status = QuoteItemUnchanged
+				if diff.Difference != 0 {
+					diff.Status = QuoteItemChanged
+				}
+			}
+		}
+
+		diffs = append(diffs, diff)
+	}
+
+	return diffs
+}
+
+func findMatchingOffer(offers []types.UnifiedOffer, target types.UnifiedOffer) (types.UnifiedOffer, bool) {
+	var (
+		best  types.UnifiedOffer
+		found bool
+	)
+
+	for _, o := range offers {
+		if o.Source != target.Source || o.SellerName != target.SellerName || o.Currency != target.Currency ||
+			!strings.EqualFold(o.MPN, target.MPN) {
+			continue
+		}
+
+		// у одного продавца может быть несколько офферов — берём самый дешёвый
+		if !found || o.Price < best.Price {
+			best = o
+			found = true
+		}
+	}
+
+	return best, found
+}
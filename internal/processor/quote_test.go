@@ -0,0 +1,31 @@
+package processor
+
+import (
+	"testing"
+
+	"dynamic-pricing-tool-ru/internal/types"
+)
+
+// Квота списывается по числу поисков, а одинаковые позиции ищутся один раз.
+func TestQuoteSearches(t *testing.T) {
+	requested := []types.QuoteRequestItem{
+		{MPN: "NE555DR", Quantity: 100},
+		{MPN: " ne555dr", Quantity: 100},
+		{MPN: "NE555DR", Quantity: 200},
+		{MPN: "LM317DCYR", Quantity: 100},
+	}
+	if got := QuoteSearches(requested); got != 3 {
+		t.Errorf("QuoteSearches = %d, want 3", got)
+	}
+
+	quote := types.Quote{Items: []types.QuoteItem{
+		{Quantity: 100, Offer: types.UnifiedOffer{MPN: "NE555DR", RequestedMPN: "NE555DR", SellerName: "A"}},
+		{Quantity: 100, Offer: types.UnifiedOffer{MPN: "NE555DR", RequestedMPN: "NE555DR", SellerName: "B"}},
+		{Quantity: 100, Offer: types.UnifiedOffer{MPN: "NE555DRG4", RequestedMPN: "NE555DR"}},
+		{Quantity: 100, Offer: types.UnifiedOffer{MPN: "LM317DCYR"}},
+		{Quantity: 200, Offer: types.UnifiedOffer{MPN: "LM317DCYR"}},
+	}}
+	if got := RepriceSearches(quote); got != 3 {
+		t.Errorf("RepriceSearches = %d, want 3", got)
+	}
+}
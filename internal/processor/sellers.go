@@ -0,0 +1,139 @@
+package processor
+
+import (
+	"strconv"
+	"strings"
+
+	"dynamic-pricing-tool-ru/internal/storage"
+	"dynamic-pricing-tool-ru/internal/types"
+)
+
+const (
+	SellerAllow = "allow"
+	SellerBlock = "block"
+)
+
+const (
+	// DefaultTrustScore — доверие к продавцу без правила
+	DefaultTrustScore = 50
+	// TrustRankWeight — насколько доверие сдвигает оффер при ранжировании:
+	// продавец с доверием 0 сортируется так, будто его цена выше на 25%,
+	// со 100 — ниже на 25%. Цены в сводке и в ответе не меняются.
+	TrustRankWeight = 0.5
+)
+
+// defaultSellerRules действуют, пока у продавца нет своего правила:
+// собственный склад Getchips — это сам дистрибьютор, а не брокер на
+// площадке, поэтому он проверен без записи в админке. Правило с тем же
+// названием (для getchips или для всех поставщиков) его заменяет.
+var defaultSellerRules = []types.SellerRule{
+	{Source: "getchips", SellerName: "Getchips", Action: SellerAllow},
+}
+
+// SellerRuleSet — правила продавцов, разложенные для поиска по складу
+// Efind и по названию. Правило с поставщиком точнее общего.
+type SellerRuleSet struct {
+	byStock map[string]types.SellerRule
+	byName  map[string]types.SellerRule
+}
+
+func NewSellerRuleSet(rules []types.SellerRule) *SellerRuleSet {
+	set := &SellerRuleSet{
+		byStock: map[string]types.SellerRule{},
+		byName:  map[string]types.SellerRule{},
+	}
+
+	for _, rule := range rules {
+		source := strings.ToLower(rule.Source)
+		if rule.StockID != 0 {
+			set.byStock[source+"|"+strconv.Itoa(rule.StockID)] = rule
+		}
+		if name := storage.NormalizeSeller(rule.SellerName); name != "" {
+			set.byName[source+"|"+name] = rule
+		}
+	}
+
+	for _, rule := range defaultSellerRules {
+		name := storage.NormalizeSeller(rule.SellerName)
+		_, exact := set.byName[rule.Source+"|"+name]
+		_, common := set.byName["|"+name]
+		if !exact && !common {
+			set.byName[rule.Source+"|"+name] = rule
+		}
+	}
+
+	return set
+}
+
+// Match ищет правило сначала по складу, затем по названию продавца.
+func (s *SellerRuleSet) Match(o types.UnifiedOffer) (types.SellerRule, bool) {
+	source := strings.ToLower(o.Source)
+
+	if o.SellerStockID != 0 {
+		stock := strconv.Itoa(o.SellerStockID)
+		for _, key := range []string{source + "|" + stock, "|" + stock} {
+			if rule, ok := s.byStock[key]; ok {
+				return rule, true
+			}
+		}
+	}
+
+	if name := storage.NormalizeSeller(o.SellerName); name != "" {
+		for _, key := range []string{source + "|" + name, "|" + name} {
+			if rule, ok := s.byName[key]; ok {
+				return rule, true
+			}
+		}
+	}
+
+	return types.SellerRule{}, false
+}
+
+// Apply убирает офферы заблокированных продавцов и проставляет
+// проверенность, доверие и заметку по правилам.
+func (s *SellerRuleSet) Apply(offers []types.UnifiedOffer) []types.UnifiedOffer {
+	result := offers[:0]
+	for _, o := range offers {
+		rule, ok := s.Match(o)
+		if !ok {
+			result = append(result, o)
+			continue
+		}
+
+		switch rule.Action {
+		case SellerBlock:
+			continue
+		case SellerAllow:
+			o.SellerVerified = true
+		}
+
+		if rule.TrustScore != nil {
+			score := *rule.TrustScore
+			o.TrustScore = &score
+		}
+		o.SellerNote = rule.Note
+
+		result = append(result, o)
+	}
+
+	return result
+}
+
+// SetSellerRules атомарно заменяет правила продавцов; вызывается при
+// старте и после каждого изменения через админский API.
+func (p *Processor) SetSellerRules(rules []types.SellerRule) {
+	p.sellerRules.Store(NewSellerRuleSet(rules))
+}
+
+func (p *Processor) SellerRules() *SellerRuleSet {
+	return p.sellerRules.Load()
+}
+
+// rankPrice — цена для сортировки с поправкой на доверие к продавцу.
+func rankPrice(o types.UnifiedOffer, price float64) float64 {
+	trust := DefaultTrustScore
+	if o.TrustScore != nil {
+		trust = *o.TrustScore
+	}
+	return price * (1 + float64(DefaultTrustScore-trust)/100*TrustRankWeight)
+}
@@ -0,0 +1,56 @@
+package processor
+
+import (
+	"testing"
+
+	"dynamic-pricing-tool-ru/internal/types"
+)
+
+// Собственный склад Getchips проверен по умолчанию, пока администратор не
+// задал для него своё правило.
+func TestDefaultSellerRules(t *testing.T) {
+	own := types.UnifiedOffer{Source: "getchips", SellerName: "Getchips"}
+	broker := types.UnifiedOffer{Source: "efind", SellerName: "Getchips"}
+
+	tests := []struct {
+		name      string
+		rules     []types.SellerRule
+		offers    []types.UnifiedOffer
+		verified  []bool
+		remaining int
+	}{
+		{
+			name:      "no rules",
+			offers:    []types.UnifiedOffer{own, broker},
+			verified:  []bool{true, false},
+			remaining: 2,
+		},
+		{
+			name:      "blocked for getchips",
+			rules:     []types.SellerRule{{Source: "getchips", SellerName: "getchips", Action: SellerBlock}},
+			offers:    []types.UnifiedOffer{own},
+			remaining: 0,
+		},
+		{
+			name:      "rule for all suppliers",
+			rules:     []types.SellerRule{{SellerName: "Getchips", Action: ""}},
+			offers:    []types.UnifiedOffer{own},
+			verified:  []bool{false},
+			remaining: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewSellerRuleSet(tt.rules).Apply(append([]types.UnifiedOffer(nil), tt.offers...))
+			if len(got) != tt.remaining {
+				t.Fatalf("offers = %+v, want %d", got, tt.remaining)
+			}
+			for i, want := range tt.verified {
+				if got[i].SellerVerified != want {
+					t.Errorf("offer %d (%s) verified = %v, want %v", i, got[i].Source, got[i].SellerVerified, want)
+				}
+			}
+		})
+	}
+}
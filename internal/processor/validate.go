@@ -0,0 +1,178 @@
+package processor
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"dynamic-pricing-tool-ru/internal/types"
+)
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+const (
+	ProblemEmptyPartNumber   = "empty_part_number"
+	ProblemPartNumberTooLong = "part_number_too_long"
+	ProblemInvalidCharacters = "invalid_characters"
+	ProblemInvalidQuantity   = "invalid_quantity"
+	ProblemQuantityDefaulted = "quantity_defaulted"
+	ProblemQuantityInferred  = "quantity_inferred"
+	ProblemDuplicateRow      = "duplicate_row"
+)
+
+// Limits — ограничения на размер BOM и содержимое ячеек.
+type Limits struct {
+	MaxRows      int
+	MaxMPNLength int
+	// AllowedMPN — допустимые символы MPN; nil отключает проверку
+	AllowedMPN *regexp.Regexp
+	// PackSizes — штук в упаковке для количеств вида "5 reels"
+	PackSizes map[string]int
+}
+
+func DefaultLimits() Limits {
+	return Limits{
+		MaxRows:      5000,
+		MaxMPNLength: 64,
+		PackSizes:    DefaultPackSizes(),
+	}
+}
+
+func (p *Processor) SetLimits(limits Limits) {
+	p.limits = limits
+}
+
+// ValidateRequest разбирает строки BOM и собирает по ним замечания.
+// Ошибка возвращается только для запроса целиком (нет маппинга, слишком
+// много строк); проблемы отдельных строк попадают в Problems.
+func (p *Processor) ValidateRequest(req *types.Request) (*types.ValidationResult, error) {
+	if len(req.Data) < 2 {
+		return nil, fmt.Errorf("insufficient data")
+	}
+
+	if p.limits.MaxRows > 0 && len(req.Data)-1 > p.limits.MaxRows {
+		return nil, fmt.Errorf("too many rows: %d, max %d", len(req.Data)-1, p.limits.MaxRows)
+	}
+
+	partNumberIndex := -1
+	quantityIndex := -1
+
+	for key, value := range req.Mapping {
+		switch value {
+		case "partNumber":
+			if idx, err := strconv.Atoi(key); err == nil {
+				partNumberIndex = idx
+			}
+		case "quantity":
+			if idx, err := strconv.Atoi(key); err == nil {
+				quantityIndex = idx
+			}
+		}
+	}
+
+	if partNumberIndex == -1 {
+		return nil, fmt.Errorf("partNumber mapping not found")
+	}
+
+	result := &types.ValidationResult{}
+	seen := map[string]int{}
+
+	problem := func(row int, field, code, severity, message, value string) {
+		result.Problems = append(result.Problems, types.RowProblem{
+			Row:      row,
+			Field:    field,
+			Code:     code,
+			Severity: severity,
+			Message:  message,
+			Value:    value,
+		})
+	}
+
+	for i := 1; i < len(req.Data); i++ {
+		row := req.Data[i]
+
+		partNumber := ""
+		if len(row) > partNumberIndex {
+			partNumber = strings.TrimSpace(row[partNumberIndex])
+		}
+
+		quantity := ""
+		if quantityIndex != -1 && len(row) > quantityIndex {
+			quantity = strings.TrimSpace(row[quantityIndex])
+		}
+
+		if partNumber == "" {
+			// полностью пустые строки в выгрузках из Excel — норма, их не отмечаем
+			if quantity != "" {
+				problem(i, "partNumber", ProblemEmptyPartNumber, SeverityError, "part number is empty", "")
+			}
+			continue
+		}
+
+		valid := true
+
+		if p.limits.MaxMPNLength > 0 && utf8.RuneCountInString(partNumber) > p.limits.MaxMPNLength {
+			problem(i, "partNumber", ProblemPartNumberTooLong, SeverityError,
+				fmt.Sprintf("part number is longer than %d characters", p.limits.MaxMPNLength), truncate(partNumber, p.limits.MaxMPNLength))
+			valid = false
+		} else if p.limits.AllowedMPN != nil && !p.limits.AllowedMPN.MatchString(partNumber) {
+			problem(i, "partNumber", ProblemInvalidCharacters, SeverityError,
+				"part number contains characters that are not allowed", partNumber)
+			valid = false
+		}
+
+		qty := 1
+		if quantityIndex != -1 {
+			parsed, inferred, err := parseQuantity(quantity, p.limits.PackSizes)
+			switch {
+			case quantity == "":
+				problem(i, "quantity", ProblemQuantityDefaulted, SeverityWarning, "quantity is empty, 1 is used", "")
+			case err != nil:
+				problem(i, "quantity", ProblemInvalidQuantity, SeverityError, err.Error(), quantity)
+				valid = false
+			default:
+				qty = parsed
+				if inferred != "" {
+					problem(i, "quantity", ProblemQuantityInferred, SeverityWarning, inferred, quantity)
+				}
+			}
+		}
+
+		if !valid {
+			continue
+		}
+
+		// повтор строки — обычное дело для BOM (разные позиционные
+		// обозначения), поэтому строка остаётся, а поставщики опрашиваются
+		// по ней один раз
+		key := partKey(partNumber, qty)
+		if first, ok := seen[key]; ok {
+			problem(i, "partNumber", ProblemDuplicateRow, SeverityWarning,
+				fmt.Sprintf("duplicate of row %d, offers are shared", first), partNumber)
+		} else {
+			seen[key] = i
+		}
+
+		result.Parts = append(result.Parts, types.PartData{
+			PartNumber: partNumber,
+			Quantity:   quantity,
+			Qty:        qty,
+			RowIndex:   i,
+		})
+	}
+
+	return result, nil
+}
+
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max]) + "..."
+}
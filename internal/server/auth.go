@@ -0,0 +1,214 @@
+package server
+
+import (
+	"crypto/subtle"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"dynamic-pricing-tool-ru/internal/logger"
+	"dynamic-pricing-tool-ru/internal/storage"
+	"dynamic-pricing-tool-ru/internal/types"
+)
+
+const (
+	AdminKeyHeader = "X-Admin-Key"
+
+	customerContextKey = "customer"
+)
+
+// rateLimiter — окно в одну минуту на клиента. Состояние в памяти процесса,
+// после рестарта окна начинаются заново.
+type rateLimiter struct {
+	mu      sync.Mutex
+	windows map[string]*rateWindow
+}
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{windows: map[string]*rateWindow{}}
+}
+
+// allow возвращает false и время до начала следующего окна, если лимит исчерпан.
+func (l *rateLimiter) allow(key string, limit int) (bool, time.Duration) {
+	if limit <= 0 {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	w, ok := l.windows[key]
+	if !ok || now.Sub(w.start) >= time.Minute {
+		w = &rateWindow{start: now}
+		l.windows[key] = w
+	}
+
+	if w.count >= limit {
+		return false, time.Minute - now.Sub(w.start)
+	}
+
+	w.count++
+	return true, 0
+}
+
+// SetAuth включает проверку API-ключей клиентов и ключа администратора.
+// При выключенной авторизации все маршруты открыты, как раньше.
+func (h *Handler) SetAuth(usage *storage.Usage, enabled bool, adminKey string) {
+	h.usage = usage
+	h.authEnabled = enabled
+	h.adminKey = adminKey
+	h.limiter = newRateLimiter()
+}
+
+func abortUnauthorized(c *gin.Context, message string) {
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+		"error": message,
+		"code":  "unauthorized",
+	})
+}
+
+// Auth проверяет X-API-Key, применяет поминутный лимит клиента и
+// сохраняет профиль в контексте для обработчиков.
+func (h *Handler) Auth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !h.authEnabled {
+			c.Next()
+			return
+		}
+
+		key := c.GetHeader(APIKeyHeader)
+		if key == "" {
+			abortUnauthorized(c, "missing API key: pass it in the "+APIKeyHeader+" header")
+			return
+		}
+
+		profile, err := h.customers.GetByAPIKey(c.Request.Context(), key)
+		if err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				abortUnauthorized(c, "invalid API key")
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		if ok, retryAfter := h.limiter.allow(profile.ID, profile.RateLimitPerMinute); !ok {
+			seconds := int(math.Ceil(retryAfter.Seconds()))
+			c.Header("Retry-After", strconv.Itoa(seconds))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error":               "rate limit exceeded",
+				"code":                "rate_limited",
+				"limit_per_minute":    profile.RateLimitPerMinute,
+				"retry_after_seconds": seconds,
+			})
+			return
+		}
+
+		if err := h.usage.RecordRequest(c.Request.Context(), profile.ID); err != nil {
+			logger.FromContext(c.Request.Context()).Error("usage update failed", zap.Error(err))
+		}
+
+		c.Set(customerContextKey, profile)
+		c.Next()
+	}
+}
+
+// AdminAuth защищает административные маршруты ключом ADMIN_API_KEY.
+func (h *Handler) AdminAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !h.requireAdmin(c) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// requireAdmin проверяет ключ администратора; при ошибке отвечает 401 и
+// возвращает false. Нужен ручкам, где под ключом только часть действий.
+// Заданный ADMIN_API_KEY проверяется всегда: AUTH_ENABLED=false открывает
+// только клиентские маршруты.
+func (h *Handler) requireAdmin(c *gin.Context) bool {
+	if !h.authEnabled && h.adminKey == "" {
+		return true
+	}
+
+	key := c.GetHeader(AdminKeyHeader)
+	if h.adminKey == "" || subtle.ConstantTimeCompare([]byte(key), []byte(h.adminKey)) != 1 {
+		abortUnauthorized(c, "admin key required in the "+AdminKeyHeader+" header")
+		return false
+	}
+
+	return true
+}
+
+// consumeRows списывает строки BOM из дневной квоты клиента. При
+// превышении отвечает 429 и возвращает false.
+func (h *Handler) consumeRows(c *gin.Context, profile *types.CustomerProfile, rows int) bool {
+	used, ok, err := h.chargeRows(c, profile, rows)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return false
+	}
+
+	if !ok {
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error":     "daily row quota exceeded",
+			"code":      "quota_exceeded",
+			"quota":     profile.DailyRowQuota,
+			"used":      used,
+			"requested": rows,
+		})
+		return false
+	}
+
+	return true
+}
+
+// chargeRows списывает строки, не отвечая клиенту: так можно пропустить
+// необязательную часть запроса вместо того, чтобы отклонять его целиком.
+func (h *Handler) chargeRows(c *gin.Context, profile *types.CustomerProfile, rows int) (int, bool, error) {
+	if profile == nil || h.usage == nil {
+		return 0, true, nil
+	}
+
+	return h.usage.ConsumeRows(c.Request.Context(), profile.ID, rows, profile.DailyRowQuota)
+}
+
+func (h *Handler) HandleUsage(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "days must be a positive number",
+		})
+		return
+	}
+
+	usage, err := h.usage.History(c.Request.Context(), c.Param("id"), days)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"customer_id": c.Param("id"),
+		"data":        usage,
+	})
+}
@@ -0,0 +1,51 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAdminAuth(t *testing.T) {
+	tests := []struct {
+		name        string
+		authEnabled bool
+		adminKey    string
+		header      string
+		want        int
+	}{
+		{name: "auth disabled, no admin key", want: http.StatusOK},
+		{name: "auth disabled, admin key set, no header", adminKey: "adm", want: http.StatusUnauthorized},
+		{name: "auth disabled, admin key set, wrong header", adminKey: "adm", header: "x", want: http.StatusUnauthorized},
+		{name: "auth disabled, admin key set, right header", adminKey: "adm", header: "adm", want: http.StatusOK},
+		{name: "auth enabled, no admin key", authEnabled: true, header: "adm", want: http.StatusUnauthorized},
+		{name: "auth enabled, right header", authEnabled: true, adminKey: "adm", header: "adm", want: http.StatusOK},
+	}
+
+	gin.SetMode(gin.TestMode)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHandler(nil, nil, nil, nil, nil, 0)
+			h.SetAuth(nil, tt.authEnabled, tt.adminKey)
+
+			router := gin.New()
+			router.GET("/admin", h.AdminAuth(), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+			if tt.header != "" {
+				req.Header.Set(AdminKeyHeader, tt.header)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
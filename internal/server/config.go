@@ -0,0 +1,57 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"dynamic-pricing-tool-ru/internal/config"
+)
+
+const ConfigVersionHeader = "X-Config-Version"
+
+// SetReloader включает перезагрузку конфигурации через admin API и
+// показ её версии в ответах.
+func (h *Handler) SetReloader(reloader *config.Reloader) {
+	h.reloader = reloader
+}
+
+func (h *Handler) configVersion() string {
+	if h.reloader == nil {
+		return ""
+	}
+	return h.reloader.Version()
+}
+
+// ConfigVersion добавляет версию действующей конфигурации в каждый ответ,
+// чтобы по логам клиента было видно, по каким правилам посчитаны цены,
+// и фиксирует параметры расчёта цен на время запроса.
+func (h *Handler) ConfigVersion() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if version := h.configVersion(); version != "" {
+			c.Header(ConfigVersionHeader, version)
+		}
+		c.Request = c.Request.WithContext(h.processor.WithPricing(c.Request.Context()))
+		c.Next()
+	}
+}
+
+// HandleConfigReload перечитывает файл конфигурации. Если новая
+// конфигурация не прошла проверку, продолжает действовать прежняя.
+func (h *Handler) HandleConfigReload(c *gin.Context) {
+	if h.reloader == nil {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": "config reload is not enabled"})
+		return
+	}
+
+	result, err := h.reloader.Reload()
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":   err.Error(),
+			"version": result.Version,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
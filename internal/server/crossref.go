@@ -0,0 +1,107 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"dynamic-pricing-tool-ru/internal/storage"
+	"dynamic-pricing-tool-ru/internal/types"
+)
+
+// SetCrossRefs подключает таблицу аналогов для админских ручек.
+func (h *Handler) SetCrossRefs(crossRefs *storage.CrossRefs) {
+	h.crossRefs = crossRefs
+}
+
+// HandleCrossRefImport загружает таблицу аналогов: CSV (text/csv) с
+// заголовком mpn,alternative,compatibility,note или JSON-массив записей.
+// С ?replace=true прежняя таблица заменяется целиком.
+func (h *Handler) HandleCrossRefImport(c *gin.Context) {
+	replace, err := strconv.ParseBool(c.DefaultQuery("replace", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "replace must be a boolean",
+		})
+		return
+	}
+
+	var refs []types.CrossReference
+
+	if strings.HasPrefix(c.ContentType(), "text/csv") {
+		refs, err = storage.ParseCrossRefCSV(c.Request.Body)
+	} else {
+		err = c.ShouldBindJSON(&refs)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	imported, err := h.crossRefs.Import(c.Request.Context(), refs, replace)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"imported": imported,
+		"skipped":  len(refs) - imported,
+	})
+}
+
+func (h *Handler) HandleCrossRefList(c *gin.Context) {
+	mpn := c.Query("mpn")
+	if mpn == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "mpn is required",
+		})
+		return
+	}
+
+	refs, err := h.crossRefs.Lookup(c.Request.Context(), mpn)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if refs == nil {
+		refs = []types.CrossReference{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": refs,
+	})
+}
+
+func (h *Handler) HandleCrossRefDelete(c *gin.Context) {
+	mpn, alternative := c.Query("mpn"), c.Query("alternative")
+	if mpn == "" || alternative == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "mpn and alternative are required",
+		})
+		return
+	}
+
+	if err := h.crossRefs.Delete(c.Request.Context(), mpn, alternative); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, storage.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,135 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"dynamic-pricing-tool-ru/internal/storage"
+	"dynamic-pricing-tool-ru/internal/types"
+)
+
+const APIKeyHeader = "X-API-Key"
+
+// resolveCustomer ищет профиль клиента по заголовку X-API-Key, а если его
+// нет — по ID из тела запроса. Без обоих возвращает nil без ошибки.
+// При включённой авторизации ID из тела не может подменить профиль ключа.
+func (h *Handler) resolveCustomer(c *gin.Context, customerID string) (*types.CustomerProfile, bool) {
+	// профиль уже найден middleware авторизации
+	if v, ok := c.Get(customerContextKey); ok {
+		return v.(*types.CustomerProfile), true
+	}
+
+	var (
+		profile *types.CustomerProfile
+		err     error
+		status  = http.StatusBadRequest
+	)
+
+	if key := c.GetHeader(APIKeyHeader); key != "" {
+		profile, err = h.customers.GetByAPIKey(c.Request.Context(), key)
+		status = http.StatusUnauthorized
+	} else if customerID != "" {
+		profile, err = h.customers.Get(c.Request.Context(), customerID)
+	} else {
+		return nil, true
+	}
+
+	if err != nil {
+		if !errors.Is(err, storage.ErrNotFound) {
+			status = http.StatusInternalServerError
+		}
+		c.JSON(status, gin.H{
+			"error": "customer not found: " + err.Error(),
+		})
+		return nil, false
+	}
+
+	return profile, true
+}
+
+// customerID — ID клиента, к которому относятся watchlist и КП; без
+// профиля — пустая строка, общая для всех анонимных вызовов.
+func customerID(profile *types.CustomerProfile) string {
+	if profile == nil {
+		return ""
+	}
+	return profile.ID
+}
+
+func (h *Handler) HandleCustomerSave(c *gin.Context) {
+	var profile types.CustomerProfile
+
+	if err := c.ShouldBindJSON(&profile); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	profile.ID = c.Param("id")
+	profile.Currency = strings.ToUpper(profile.Currency)
+
+	if profile.MarkupCoef < 0 || profile.RateLimitPerMinute < 0 || profile.DailyRowQuota < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "markup_coef, rate_limit_per_minute and daily_row_quota must not be negative",
+		})
+		return
+	}
+
+	if err := h.customers.Save(c.Request.Context(), profile); err != nil {
+		if errors.Is(err, storage.ErrConflict) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	saved, err := h.customers.Get(c.Request.Context(), profile.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": saved,
+	})
+}
+
+func (h *Handler) HandleCustomerList(c *gin.Context) {
+	profiles, err := h.customers.List(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": profiles,
+	})
+}
+
+func (h *Handler) HandleCustomerDelete(c *gin.Context) {
+	if err := h.customers.Delete(c.Request.Context(), c.Param("id")); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, storage.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -1,35 +1,136 @@
 package server
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"dynamic-pricing-tool-ru/internal/config"
 	"dynamic-pricing-tool-ru/internal/processor"
+	"dynamic-pricing-tool-ru/internal/storage"
 	"dynamic-pricing-tool-ru/internal/types"
 )
 
 type Handler struct {
-	processor *processor.Processor
+	processor   *processor.Processor
+	history     *storage.PriceHistory
+	watchlist   *storage.Watchlist
+	quotes      *storage.Quotes
+	customers   *storage.Customers
+	crossRefs   *storage.CrossRefs
+	sellerRules *storage.SellerRules
+
+	quoteValidDays int
+	maxBodyBytes   int64
+
+	ready     atomic.Bool
+	inFlight  sync.WaitGroup
+	canaryMPN string
+
+	usage       *storage.Usage
+	authEnabled bool
+	adminKey    string
+	limiter     *rateLimiter
+
+	reloader *config.Reloader
 }
 
-func NewHandler(proc *processor.Processor) *Handler {
-	return &Handler{
-		processor: proc,
+func NewHandler(proc *processor.Processor, history *storage.PriceHistory, watchlist *storage.Watchlist, quotes *storage.Quotes, customers *storage.Customers, quoteValidDays int) *Handler {
+	h := &Handler{
+		processor:      proc,
+		history:        history,
+		watchlist:      watchlist,
+		quotes:         quotes,
+		customers:      customers,
+		quoteValidDays: quoteValidDays,
 	}
+	h.ready.Store(true)
+
+	return h
 }
 
+// SetMaxBodyBytes ограничивает размер тела запроса /process.
+func (h *Handler) SetMaxBodyBytes(limit int64) {
+	h.maxBodyBytes = limit
+}
+
+// Формы ответа /process: плоский список офферов или строки BOM с
+// вложенными офферами и сводкой.
+const (
+	ViewOffers = "offers"
+	ViewParts  = "parts"
+)
+
 func (h *Handler) HandleProcess(c *gin.Context) {
 	var req types.Request
 
+	view := c.DefaultQuery("view", ViewOffers)
+	if view != ViewOffers && view != ViewParts {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("unknown view %q, expected %q or %q", view, ViewOffers, ViewParts),
+		})
+		return
+	}
+
+	withAnalysis, err := strconv.ParseBool(c.DefaultQuery("analysis", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "analysis must be a boolean",
+		})
+		return
+	}
+
+	if h.maxBodyBytes > 0 {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxBodyBytes)
+	}
+
 	if err := c.ShouldBindJSON(&req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+				"error": fmt.Sprintf("request body exceeds %d bytes", tooLarge.Limit),
+			})
+			return
+		}
+
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": err.Error(),
 		})
 		return
 	}
 
-	offers, err := h.processor.ProcessRequest(c.Request.Context(), &req)
+	profile, ok := h.resolveCustomer(c, req.Customer)
+	if !ok {
+		return
+	}
+
+	validation, err := h.processor.ValidateRequest(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if validation.HasErrors() && !req.SkipInvalid {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":    "validation failed",
+			"problems": validation.Problems,
+		})
+		return
+	}
+
+	if len(validation.Parts) > 0 && !h.consumeRows(c, profile, len(validation.Parts)) {
+		return
+	}
+
+	offers, err := h.processor.ProcessParts(c.Request.Context(), validation.Parts)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": err.Error(),
@@ -37,16 +138,98 @@ func (h *Handler) HandleProcess(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data":   offers,
+	// поиск аналогов — такие же запросы к поставщикам, их строки тоже
+	// списываются; без квоты на них ответ просто приходит без аналогов
+	var warnings []string
+	plan := h.processor.PlanAlternatives(c.Request.Context(), validation.Parts, offers)
+	if rows := plan.Rows(); rows > 0 {
+		_, ok, err := h.chargeRows(c, profile, rows)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		if ok {
+			alternatives, err := h.processor.SearchAlternatives(c.Request.Context(), plan)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error": err.Error(),
+				})
+				return
+			}
+			offers = append(offers, alternatives...)
+		} else {
+			warnings = append(warnings, fmt.Sprintf("alternatives skipped: %d substitute searches exceed the daily row quota", rows))
+		}
+	}
+
+	if profile != nil {
+		offers = h.processor.ApplyCustomerProfile(c.Request.Context(), offers, profile)
+	}
+
+	response := gin.H{
 		"status": "COMPLETED",
-	})
+	}
+	currency := ""
+	if profile != nil {
+		currency = profile.Currency
+	}
+	if view == ViewParts {
+		response["data"] = h.processor.GroupByPart(c.Request.Context(), validation.Parts, offers, currency)
+	} else {
+		response["data"] = h.processor.RankOffers(c.Request.Context(), validation.Parts, offers, currency)
+	}
+	if withAnalysis {
+		response["analysis"] = h.processor.AnalyzeResults(c.Request.Context(), validation.Parts, offers, currency)
+	}
+	if len(validation.Problems) > 0 {
+		response["problems"] = validation.Problems
+	}
+	if len(warnings) > 0 {
+		response["warnings"] = warnings
+	}
+
+	c.JSON(http.StatusOK, response)
 }
 
-func (h *Handler) HealthCheck(c *gin.Context) {
+func (h *Handler) HandleHistory(c *gin.Context) {
+	mpn := c.Query("mpn")
+	if mpn == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "mpn is required",
+		})
+		return
+	}
+
+	var from, to time.Time
+	for param, dst := range map[string]*time.Time{"from": &from, "to": &to} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": param + " must be RFC3339 timestamp",
+			})
+			return
+		}
+		*dst = t
+	}
+
+	series, err := h.history.Series(c.Request.Context(), mpn, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"status":  "healthy",
-		"service": "part-api-processor",
-		"apis":    []string{"Getchips", "Efind", "Promelec"},
+		"mpn":     mpn,
+		"sellers": series,
 	})
 }
@@ -0,0 +1,139 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"dynamic-pricing-tool-ru/internal/api"
+)
+
+// SetReady переключает ответ /health/ready; при остановке сервера флаг
+// сбрасывается раньше, чем закрывается listener.
+func (h *Handler) SetReady(ready bool) {
+	h.ready.Store(ready)
+}
+
+// TrackInFlight учитывает запросы, которые ещё обрабатываются: после
+// srv.Close обработчики продолжают работать, а история и база должны
+// закрываться только после них.
+func (h *Handler) TrackInFlight() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		h.inFlight.Add(1)
+		defer h.inFlight.Done()
+
+		c.Next()
+	}
+}
+
+// WaitInFlight дожидается запросов, принятых до остановки сервера.
+func (h *Handler) WaitInFlight() {
+	h.inFlight.Wait()
+}
+
+// SetHealthConfig задаёт канареечный MPN для активной проверки поставщиков.
+func (h *Handler) SetHealthConfig(canaryMPN string) {
+	h.canaryMPN = canaryMPN
+}
+
+// configProblems — замечания к действующей конфигурации; после
+// перезагрузки список пересчитывается.
+func (h *Handler) configProblems() []string {
+	if h.reloader == nil {
+		return nil
+	}
+	return h.reloader.Current().Problems()
+}
+
+func (h *Handler) HealthCheck(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status":         "healthy",
+		"service":        "part-api-processor",
+		"apis":           h.processor.CombinedClient().Enabled(),
+		"config_version": h.configVersion(),
+	})
+}
+
+func (h *Handler) HandleLive(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status": "alive",
+	})
+}
+
+// HandleReady отвечает 503 при остановке сервера и когда все поставщики
+// отключены автоматом или в конфиге: в этом состоянии /process вернёт
+// пустой результат.
+func (h *Handler) HandleReady(c *gin.Context) {
+	if !h.ready.Load() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status": "shutting_down",
+		})
+		return
+	}
+
+	available := 0
+	for _, s := range h.processor.CombinedClient().Health() {
+		if s.Circuit != api.CircuitOpen && s.Circuit != api.CircuitDisabled {
+			available++
+		}
+	}
+
+	if available == 0 {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status": "no_suppliers_available",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ready",
+	})
+}
+
+// HandleSuppliers — подробный отчёт по поставщикам. С ?probe=true к каждому
+// поставщику уходит запрос канареечного MPN (HEALTH_CANARY_MPN); проба
+// тратит лимиты поставщиков, поэтому доступна только с ключом
+// администратора.
+func (h *Handler) HandleSuppliers(c *gin.Context) {
+	client := h.processor.CombinedClient()
+
+	probe, _ := strconv.ParseBool(c.Query("probe"))
+	if probe && !h.requireAdmin(c) {
+		return
+	}
+	if probe && h.canaryMPN == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "active probe is disabled: HEALTH_CANARY_MPN is not set",
+		})
+		return
+	}
+
+	statuses := client.Health()
+
+	if probe {
+		results := client.Probe(c.Request.Context(), h.canaryMPN)
+		// статистику перечитываем, чтобы она включала результат пробы
+		statuses = client.Health()
+		for i := range statuses {
+			if r, ok := results[statuses[i].Name]; ok {
+				statuses[i].Probe = &r
+			}
+		}
+	}
+
+	problems := h.configProblems()
+	configStatus := "ok"
+	if len(problems) > 0 {
+		configStatus = "warnings"
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"ready":     h.ready.Load(),
+		"suppliers": statuses,
+		"config": gin.H{
+			"status":   configStatus,
+			"problems": problems,
+		},
+	})
+}
@@ -0,0 +1,234 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"dynamic-pricing-tool-ru/internal/api"
+	"dynamic-pricing-tool-ru/internal/config"
+	"dynamic-pricing-tool-ru/internal/fixtures"
+	"dynamic-pricing-tool-ru/internal/logger"
+	"dynamic-pricing-tool-ru/internal/processor"
+	"dynamic-pricing-tool-ru/internal/types"
+)
+
+// Фикстуры записаны сервером в режиме SUPPLIER_FIXTURES=record; чтобы
+// обновить их, запустите его с record и тем же каталогом и повторите
+// запрос из теста.
+const fixturesDir = "../../testdata/fixtures"
+
+// newReplayRouter собирает /process так же, как main, но поставщики
+// отвечают из фикстур, без сети и без настоящих учётных данных.
+func newReplayRouter(t *testing.T) (*gin.Engine, *processor.Processor) {
+	t.Helper()
+
+	cfg := config.Default()
+	cfg.SupplierFixtures = fixtures.ModeReplay
+	cfg.SupplierFixturesDir = fixturesDir
+	cfg.USDRate = 90
+
+	if errs := cfg.Validate(); len(errs) > 0 {
+		t.Fatalf("replay config without supplier tokens is invalid: %v", errs)
+	}
+
+	if err := logger.Init(logger.Options{
+		Level:       "error",
+		Output:      logger.OutputStdout,
+		BodyCapture: logger.BodyCaptureOff,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	logger.SetRedactor(logger.NewRedactor(cfg.RedactQueryParams, cfg.RedactBodyFields, cfg.Secrets()))
+
+	transport, err := fixtures.Transport(cfg.SupplierFixtures, cfg.SupplierFixturesDir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	api.SetTransport(transport)
+	t.Cleanup(func() { api.SetTransport(nil) })
+
+	s := cfg.Suppliers
+	proc := processor.NewProcessorWithClients(
+		api.NewGetchipsClient(s.Getchips.URL, s.Getchips.Token, s.Getchips.Timeout),
+		api.NewEfindClient(s.Efind.URL, s.Efind.Token, s.Efind.Timeout),
+		api.NewPromelecClient(s.Promelec.URL, s.Promelec.Login, s.Promelec.Password, s.Promelec.Timeout),
+		cfg.ChunkSize,
+	)
+	proc.SetPricing(processor.Pricing{
+		VATRate: cfg.VATRate,
+		Rates:   map[string]float64{"USD": cfg.USDRate},
+	})
+
+	handler := NewHandler(proc, nil, nil, nil, nil, cfg.QuoteValidDays)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(handler.ConfigVersion())
+	router.POST("/process", handler.HandleProcess)
+
+	return router, proc
+}
+
+func TestProcessReplay(t *testing.T) {
+	router, _ := newReplayRouter(t)
+
+	body := `{
+		"mapping": {"0": "partNumber", "1": "quantity"},
+		"data": [
+			["MPN", "Qty"],
+			["NE555DR", "25"],
+			["LM317DCYR", "100"],
+			["BSS84AKW", "3000"]
+		]
+	}`
+
+	req := httptest.NewRequest(http.MethodPost, "/process?view=parts&analysis=true", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp struct {
+		Status   string               `json:"status"`
+		Data     []types.PartView     `json:"data"`
+		Analysis types.AnalysisResult `json:"analysis"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(resp.Data) != 3 {
+		t.Fatalf("got %d rows, want 3", len(resp.Data))
+	}
+
+	sources := func(view types.PartView) map[string]int {
+		counts := map[string]int{}
+		for _, o := range view.Offers {
+			counts[o.Source]++
+		}
+		return counts
+	}
+
+	ne555 := resp.Data[0]
+	if ne555.RequestedMPN != "NE555DR" || ne555.Summary.Offers == 0 {
+		t.Fatalf("NE555DR row has no offers: %+v", ne555)
+	}
+	if got := sources(ne555); got[api.SupplierGetchips] == 0 || got[api.SupplierEfind] == 0 || got[api.SupplierPromelec] == 0 {
+		t.Errorf("NE555DR offers by source = %v, want all three suppliers", got)
+	}
+	if ne555.Summary.MinPrice <= 0 {
+		t.Errorf("NE555DR min price = %v, want positive", ne555.Summary.MinPrice)
+	}
+
+	if lm317 := resp.Data[1]; lm317.Summary.Offers == 0 {
+		t.Errorf("LM317DCYR row has no offers: %+v", lm317)
+	}
+
+	analyzed := map[string]bool{}
+	for _, s := range resp.Analysis.Suppliers {
+		analyzed[s.Source] = true
+	}
+	for _, source := range []string{api.SupplierGetchips, api.SupplierEfind, api.SupplierPromelec} {
+		if !analyzed[source] {
+			t.Errorf("analysis has no %s section: %+v", source, resp.Analysis.Suppliers)
+		}
+	}
+
+	if missing := resp.Data[2]; missing.RequestedMPN != "BSS84AKW" || len(missing.Offers) != 0 {
+		t.Errorf("BSS84AKW should have no offers, got %+v", missing.Offers)
+	}
+}
+
+// Правила продавцов работают и на плоском списке: склад Efind находится
+// по stock_id, заблокированный убран, проверенными считаются продавец с
+// правилом allow и собственный склад Getchips, а доверие меняет порядок
+// офферов.
+func TestProcessReplaySellerRules(t *testing.T) {
+	router, proc := newReplayRouter(t)
+
+	trusted := 100
+	proc.SetSellerRules([]types.SellerRule{
+		{Source: api.SupplierEfind, StockID: 1101, Action: processor.SellerAllow, TrustScore: &trusted},
+		{Source: api.SupplierEfind, StockID: 2204, Action: processor.SellerBlock},
+	})
+
+	body := `{
+		"mapping": {"0": "partNumber", "1": "quantity"},
+		"data": [
+			["MPN", "Qty"],
+			["NE555DR", "25"]
+		]
+	}`
+
+	req := httptest.NewRequest(http.MethodPost, "/process", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp struct {
+		Data []types.UnifiedOffer `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Data) == 0 {
+		t.Fatal("no offers")
+	}
+
+	trustedAt, promelecAt := -1, -1
+	for i, o := range resp.Data {
+		switch {
+		case o.SellerStockID == 2204:
+			t.Errorf("blocked efind stock 2204 is in the output: %+v", o)
+		case o.SellerStockID == 1101:
+			trustedAt = i
+			if !o.SellerVerified || o.TrustScore == nil || *o.TrustScore != trusted {
+				t.Errorf("efind stock 1101 should be verified with trust %d: %+v", trusted, o)
+			}
+		case o.Source == api.SupplierGetchips && o.SellerName == "Getchips":
+			if !o.SellerVerified {
+				t.Errorf("getchips own stock should be verified by default: %+v", o)
+			}
+		case o.SellerVerified:
+			t.Errorf("%s offer is verified without an allow rule: %+v", o.Source, o)
+		}
+		if o.Source == api.SupplierPromelec && promelecAt < 0 {
+			promelecAt = i
+		}
+	}
+	if trustedAt < 0 || promelecAt < 0 {
+		t.Fatalf("efind stock 1101 or promelec is missing: %+v", resp.Data)
+	}
+
+	// у склада 1101 цена выше, чем у Promelec (41.5 против 38.5 ₽),
+	// но полное доверие поднимает его выше
+	if trustedAt > promelecAt {
+		t.Errorf("trusted efind stock 1101 at %d, promelec at %d: trust does not affect ranking", trustedAt, promelecAt)
+	}
+}
+
+// Запрос без записанной фикстуры должен падать, а не тихо возвращать пустой
+// результат, иначе пропущенная запись останется незамеченной.
+func TestReplayMissingFixture(t *testing.T) {
+	transport, err := fixtures.Transport(fixtures.ModeReplay, fixturesDir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "https://efind.ru/api/search/UNKNOWN-MPN?access_token=x", nil)
+	if _, err := transport.RoundTrip(req); err == nil || !strings.Contains(err.Error(), "no fixture") {
+		t.Fatalf("expected missing fixture error, got %v", err)
+	}
+}
@@ -0,0 +1,130 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"dynamic-pricing-tool-ru/internal/processor"
+	"dynamic-pricing-tool-ru/internal/storage"
+	"dynamic-pricing-tool-ru/internal/types"
+)
+
+func (h *Handler) HandleQuoteCreate(c *gin.Context) {
+	var req types.QuoteRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	profile, ok := h.resolveCustomer(c, req.Customer)
+	if !ok {
+		return
+	}
+
+	validDays := req.ValidDays
+	if validDays <= 0 {
+		validDays = h.quoteValidDays
+	}
+
+	if !h.consumeRows(c, profile, processor.QuoteSearches(req.Items)) {
+		return
+	}
+
+	items, err := h.processor.BuildQuoteItems(c.Request.Context(), req.Items, profile)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, processor.ErrQuoteOfferNotFound) {
+			status = http.StatusUnprocessableEntity
+		}
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	now := time.Now().UTC()
+
+	quote, err := h.quotes.Create(c.Request.Context(), types.Quote{
+		Customer:   customerID(profile),
+		Note:       req.Note,
+		Items:      items,
+		CreatedAt:  now,
+		ValidUntil: now.AddDate(0, 0, validDays),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	quote.Totals = processor.QuoteTotals(quote.Items)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"data": quote,
+	})
+}
+
+func (h *Handler) HandleQuoteGet(c *gin.Context) {
+	quote, _, ok := h.loadQuote(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": quote,
+	})
+}
+
+// HandleQuoteReprice заново опрашивает поставщиков по всем позициям КП,
+// поэтому списывает их из квоты клиента так же, как /process.
+func (h *Handler) HandleQuoteReprice(c *gin.Context) {
+	quote, profile, ok := h.loadQuote(c)
+	if !ok {
+		return
+	}
+
+	if !h.consumeRows(c, profile, processor.RepriceSearches(quote)) {
+		return
+	}
+
+	diffs := h.processor.RepriceQuote(c.Request.Context(), quote, profile)
+
+	c.JSON(http.StatusOK, gin.H{
+		"number":  quote.Number,
+		"expired": quote.Expired,
+		"items":   diffs,
+	})
+}
+
+// loadQuote отдаёт КП только его клиенту: для чужого номера ответ тот же,
+// что и для несуществующего. Клиент определяется по ключу или ?customer=.
+func (h *Handler) loadQuote(c *gin.Context) (types.Quote, *types.CustomerProfile, bool) {
+	profile, ok := h.resolveCustomer(c, c.Query("customer"))
+	if !ok {
+		return types.Quote{}, nil, false
+	}
+
+	quote, err := h.quotes.Get(c.Request.Context(), c.Param("number"), customerID(profile))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, storage.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return quote, nil, false
+	}
+
+	quote.Totals = processor.QuoteTotals(quote.Items)
+	quote.Expired = time.Now().After(quote.ValidUntil)
+
+	return quote, profile, true
+}
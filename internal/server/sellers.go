@@ -0,0 +1,147 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"dynamic-pricing-tool-ru/internal/processor"
+	"dynamic-pricing-tool-ru/internal/storage"
+	"dynamic-pricing-tool-ru/internal/types"
+)
+
+// SetSellerRules подключает хранилище правил продавцов для админских ручек.
+func (h *Handler) SetSellerRules(rules *storage.SellerRules) {
+	h.sellerRules = rules
+}
+
+// reloadSellerRules передаёт процессору актуальный набор правил, чтобы
+// изменение действовало со следующего запроса.
+func (h *Handler) reloadSellerRules(ctx context.Context) error {
+	rules, err := h.sellerRules.List(ctx)
+	if err != nil {
+		return err
+	}
+	h.processor.SetSellerRules(rules)
+	return nil
+}
+
+func validateSellerRule(rule types.SellerRule) string {
+	switch {
+	case rule.SellerName == "" && rule.StockID == 0:
+		return "seller_name or stock_id is required"
+	case rule.Action != "" && rule.Action != processor.SellerAllow && rule.Action != processor.SellerBlock:
+		return "action must be allow, block or empty"
+	case rule.TrustScore != nil && (*rule.TrustScore < 0 || *rule.TrustScore > 100):
+		return "trust_score must be between 0 and 100"
+	}
+	return ""
+}
+
+func (h *Handler) HandleSellerRuleList(c *gin.Context) {
+	rules, err := h.sellerRules.List(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if rules == nil {
+		rules = []types.SellerRule{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": rules,
+	})
+}
+
+// HandleSellerRuleSave создаёт правило (POST) или заменяет его по ID (PUT).
+func (h *Handler) HandleSellerRuleSave(c *gin.Context) {
+	var rule types.SellerRule
+
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	rule.ID = 0
+	status := http.StatusCreated
+
+	if param := c.Param("id"); param != "" {
+		id, err := strconv.ParseInt(param, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid id",
+			})
+			return
+		}
+		rule.ID = id
+		status = http.StatusOK
+	}
+
+	if problem := validateSellerRule(rule); problem != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": problem,
+		})
+		return
+	}
+
+	rule, err := h.sellerRules.Save(c.Request.Context(), rule)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, storage.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if err := h.reloadSellerRules(c.Request.Context()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(status, gin.H{
+		"data": rule,
+	})
+}
+
+func (h *Handler) HandleSellerRuleDelete(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid id",
+		})
+		return
+	}
+
+	if err := h.sellerRules.Delete(c.Request.Context(), id); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, storage.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if err := h.reloadSellerRules(c.Request.Context()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
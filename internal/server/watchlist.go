@@ -0,0 +1,102 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"dynamic-pricing-tool-ru/internal/storage"
+	"dynamic-pricing-tool-ru/internal/types"
+)
+
+func (h *Handler) HandleWatchAdd(c *gin.Context) {
+	var item types.WatchItem
+
+	if err := c.ShouldBindJSON(&item); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if item.PriceDropPercent < 0 || item.MinStock < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "thresholds must not be negative",
+		})
+		return
+	}
+
+	if item.PriceDropPercent == 0 && item.MinStock == 0 && !item.NotifyNewSeller {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "at least one threshold is required: price_drop_pct, min_stock or notify_new_seller",
+		})
+		return
+	}
+
+	profile, ok := h.resolveCustomer(c, item.CustomerID)
+	if !ok {
+		return
+	}
+	item.CustomerID = customerID(profile)
+
+	item, err := h.watchlist.Add(c.Request.Context(), item)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"data": item,
+	})
+}
+
+func (h *Handler) HandleWatchList(c *gin.Context) {
+	profile, ok := h.resolveCustomer(c, c.Query("customer"))
+	if !ok {
+		return
+	}
+
+	items, err := h.watchlist.ListByCustomer(c.Request.Context(), customerID(profile))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": items,
+	})
+}
+
+func (h *Handler) HandleWatchDelete(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid id",
+		})
+		return
+	}
+
+	profile, ok := h.resolveCustomer(c, c.Query("customer"))
+	if !ok {
+		return
+	}
+
+	if err := h.watchlist.Delete(c.Request.Context(), id, customerID(profile)); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, storage.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,194 @@
+package storage
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"dynamic-pricing-tool-ru/internal/types"
+)
+
+// CrossRefs — локальная таблица аналогов, которую ведут закупщики.
+type CrossRefs struct {
+	db *DB
+}
+
+func NewCrossRefs(db *DB) *CrossRefs {
+	return &CrossRefs{db: db}
+}
+
+// Import добавляет или обновляет записи одной транзакцией. С replace
+// таблица предварительно очищается.
+func (c *CrossRefs) Import(ctx context.Context, refs []types.CrossReference, replace bool) (int, error) {
+	tx, err := c.db.sql.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("import cross references: %w", err)
+	}
+	defer tx.Rollback()
+
+	if replace {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM cross_references`); err != nil {
+			return 0, fmt.Errorf("clear cross references: %w", err)
+		}
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO cross_references
+		(mpn_norm, alternative_norm, mpn, alternative, compatibility, note, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (mpn_norm, alternative_norm) DO UPDATE SET
+			mpn = excluded.mpn,
+			alternative = excluded.alternative,
+			compatibility = excluded.compatibility,
+			note = excluded.note,
+			updated_at = excluded.updated_at`)
+	if err != nil {
+		return 0, fmt.Errorf("import cross references: %w", err)
+	}
+	defer stmt.Close()
+
+	now := time.Now().UTC().Unix()
+	imported := 0
+
+	for _, ref := range refs {
+		mpn, alt := strings.TrimSpace(ref.MPN), strings.TrimSpace(ref.Alternative)
+		if mpn == "" || alt == "" || NormalizeMPN(mpn) == NormalizeMPN(alt) {
+			continue
+		}
+
+		if _, err := stmt.ExecContext(ctx,
+			NormalizeMPN(mpn),
+			NormalizeMPN(alt),
+			mpn,
+			alt,
+			strings.TrimSpace(ref.Compatibility),
+			strings.TrimSpace(ref.Note),
+			now,
+		); err != nil {
+			return 0, fmt.Errorf("insert cross reference %s -> %s: %w", mpn, alt, err)
+		}
+		imported++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("import cross references: %w", err)
+	}
+
+	return imported, nil
+}
+
+// Lookup возвращает аналоги, которыми можно заменить mpn.
+func (c *CrossRefs) Lookup(ctx context.Context, mpn string) ([]types.CrossReference, error) {
+	rows, err := c.db.sql.QueryContext(ctx, `SELECT
+		mpn, alternative, compatibility, note, updated_at
+		FROM cross_references WHERE mpn_norm = ? ORDER BY alternative_norm`,
+		NormalizeMPN(mpn),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query cross references: %w", err)
+	}
+	defer rows.Close()
+
+	var refs []types.CrossReference
+	for rows.Next() {
+		var (
+			ref       types.CrossReference
+			updatedAt int64
+		)
+
+		if err := rows.Scan(&ref.MPN, &ref.Alternative, &ref.Compatibility, &ref.Note, &updatedAt); err != nil {
+			return nil, fmt.Errorf("scan cross references: %w", err)
+		}
+		ref.UpdatedAt = time.Unix(updatedAt, 0).UTC()
+
+		refs = append(refs, ref)
+	}
+
+	return refs, rows.Err()
+}
+
+func (c *CrossRefs) Delete(ctx context.Context, mpn, alternative string) error {
+	res, err := c.db.sql.ExecContext(ctx,
+		`DELETE FROM cross_references WHERE mpn_norm = ? AND alternative_norm = ?`,
+		NormalizeMPN(mpn),
+		NormalizeMPN(alternative),
+	)
+	if err != nil {
+		return fmt.Errorf("delete cross reference: %w", err)
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// ParseCrossRefCSV читает таблицу аналогов с заголовком
+// mpn,alternative[,compatibility][,note]. Разделитель — запятая или точка
+// с запятой (выгрузка из Excel), определяется по строке заголовка.
+func ParseCrossRefCSV(r io.Reader) ([]types.CrossReference, error) {
+	br := bufio.NewReader(r)
+
+	header, err := br.Peek(4096)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, err
+	}
+	firstLine, _, _ := strings.Cut(string(header), "\n")
+
+	cr := csv.NewReader(br)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	if strings.Count(firstLine, ";") > strings.Count(firstLine, ",") {
+		cr.Comma = ';'
+	}
+
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("parse csv: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("parse csv: file is empty")
+	}
+
+	columns := map[string]int{}
+	for i, name := range records[0] {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		columns[name] = i
+	}
+
+	mpnCol, ok := columns["mpn"]
+	if !ok {
+		return nil, fmt.Errorf("parse csv: header must contain mpn column")
+	}
+	altCol, ok := columns["alternative"]
+	if !ok {
+		return nil, fmt.Errorf("parse csv: header must contain alternative column")
+	}
+
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return record[i]
+		}
+		return ""
+	}
+
+	var refs []types.CrossReference
+	for line, record := range records[1:] {
+		if mpnCol >= len(record) || altCol >= len(record) {
+			return nil, fmt.Errorf("parse csv: line %d: expected mpn and alternative", line+2)
+		}
+
+		refs = append(refs, types.CrossReference{
+			MPN:           record[mpnCol],
+			Alternative:   record[altCol],
+			Compatibility: field(record, "compatibility"),
+			Note:          field(record, "note"),
+		})
+	}
+
+	return refs, nil
+}
@@ -0,0 +1,162 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+
+	"dynamic-pricing-tool-ru/internal/types"
+)
+
+type Customers struct {
+	db *DB
+}
+
+func NewCustomers(db *DB) *Customers {
+	return &Customers{db: db}
+}
+
+// HashAPIKey — ключи хранятся только в виде sha256.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Save создаёт или обновляет профиль. Пустой APIKey сохраняет прежний ключ.
+func (c *Customers) Save(ctx context.Context, profile types.CustomerProfile) error {
+	excluded, err := json.Marshal(profile.ExcludedSuppliers)
+	if err != nil {
+		return fmt.Errorf("encode excluded suppliers: %w", err)
+	}
+
+	keyHash := ""
+	if profile.APIKey != "" {
+		keyHash = HashAPIKey(profile.APIKey)
+	}
+
+	_, err = c.db.sql.ExecContext(ctx, `INSERT INTO customers
+		(id, name, api_key_hash, markup_coef, currency, include_vat, excluded_suppliers,
+		 rate_limit_per_minute, daily_row_quota, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			name = excluded.name,
+			api_key_hash = CASE WHEN excluded.api_key_hash = '' THEN customers.api_key_hash ELSE excluded.api_key_hash END,
+			markup_coef = excluded.markup_coef,
+			currency = excluded.currency,
+			include_vat = excluded.include_vat,
+			excluded_suppliers = excluded.excluded_suppliers,
+			rate_limit_per_minute = excluded.rate_limit_per_minute,
+			daily_row_quota = excluded.daily_row_quota,
+			updated_at = excluded.updated_at`,
+		profile.ID,
+		profile.Name,
+		keyHash,
+		profile.MarkupCoef,
+		profile.Currency,
+		profile.IncludeVAT,
+		string(excluded),
+		profile.RateLimitPerMinute,
+		profile.DailyRowQuota,
+		time.Now().UTC().Unix(),
+	)
+	if isUniqueViolation(err) {
+		return fmt.Errorf("%w: API key is already used by another customer", ErrConflict)
+	}
+	if err != nil {
+		return fmt.Errorf("save customer: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Customers) Delete(ctx context.Context, id string) error {
+	res, err := c.db.sql.ExecContext(ctx, `DELETE FROM customers WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("delete customer: %w", err)
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (c *Customers) Get(ctx context.Context, id string) (*types.CustomerProfile, error) {
+	return c.queryOne(ctx, `WHERE id = ?`, id)
+}
+
+func (c *Customers) GetByAPIKey(ctx context.Context, key string) (*types.CustomerProfile, error) {
+	return c.queryOne(ctx, `WHERE api_key_hash = ?`, HashAPIKey(key))
+}
+
+func (c *Customers) List(ctx context.Context) ([]types.CustomerProfile, error) {
+	rows, err := c.db.sql.QueryContext(ctx, customerSelect+` ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("query customers: %w", err)
+	}
+	defer rows.Close()
+
+	var profiles []types.CustomerProfile
+	for rows.Next() {
+		profile, err := scanCustomer(rows)
+		if err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, *profile)
+	}
+
+	return profiles, rows.Err()
+}
+
+const customerSelect = `SELECT id, name, markup_coef, currency, include_vat, excluded_suppliers,
+	rate_limit_per_minute, daily_row_quota, updated_at
+	FROM customers`
+
+func (c *Customers) queryOne(ctx context.Context, where string, arg interface{}) (*types.CustomerProfile, error) {
+	profile, err := scanCustomer(c.db.sql.QueryRowContext(ctx, customerSelect+" "+where, arg))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return profile, err
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanCustomer(row rowScanner) (*types.CustomerProfile, error) {
+	var (
+		profile   types.CustomerProfile
+		excluded  string
+		updatedAt int64
+	)
+
+	if err := row.Scan(&profile.ID, &profile.Name, &profile.MarkupCoef, &profile.Currency,
+		&profile.IncludeVAT, &excluded, &profile.RateLimitPerMinute, &profile.DailyRowQuota, &updatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("scan customer: %w", err)
+	}
+
+	if err := json.Unmarshal([]byte(excluded), &profile.ExcludedSuppliers); err != nil {
+		return nil, fmt.Errorf("decode excluded suppliers: %w", err)
+	}
+	profile.UpdatedAt = time.Unix(updatedAt, 0).UTC()
+
+	return &profile, nil
+}
+
+func isUniqueViolation(err error) bool {
+	var sqliteErr *sqlite.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE
+}
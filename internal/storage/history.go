@@ -0,0 +1,218 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"dynamic-pricing-tool-ru/internal/logger"
+	"dynamic-pricing-tool-ru/internal/types"
+)
+
+type priceRecord struct {
+	MPN        string
+	SellerName string
+	Source     string
+	Quantity   int
+	Price      float64
+	Currency   string
+	Stock      int
+	RecordedAt time.Time
+}
+
+// PriceHistory асинхронно пишет цены офферов в SQLite, чтобы запись не
+// задерживала ответ /process.
+type PriceHistory struct {
+	db    *DB
+	queue chan []priceRecord
+	wg    sync.WaitGroup
+
+	// mu защищает queue от записи после Close: воркеры отменённых запросов
+	// могут досылать офферы уже во время остановки сервера
+	mu     sync.RWMutex
+	closed bool
+}
+
+func NewPriceHistory(db *DB, bufferSize int) *PriceHistory {
+	h := &PriceHistory{
+		db:    db,
+		queue: make(chan []priceRecord, bufferSize),
+	}
+
+	h.wg.Add(1)
+	go h.writer()
+
+	return h
+}
+
+func NormalizeMPN(mpn string) string {
+	return strings.ToUpper(strings.TrimSpace(mpn))
+}
+
+// Record ставит офферы в очередь на запись. Если очередь переполнена,
+// пачка отбрасывается — история не должна тормозить обработку BOM.
+func (h *PriceHistory) Record(offers []types.UnifiedOffer) {
+	if len(offers) == 0 {
+		return
+	}
+
+	now := time.Now().UTC()
+
+	var records []priceRecord
+	for _, o := range offers {
+		for _, pb := range o.PriceBreaks {
+			records = append(records, priceRecord{
+				MPN:        o.MPN,
+				SellerName: o.SellerName,
+				Source:     o.Source,
+				Quantity:   pb.Quantity,
+				Price:      pb.Price,
+				Currency:   pb.Currency,
+				Stock:      o.Stock,
+				RecordedAt: now,
+			})
+		}
+	}
+
+	if len(records) == 0 {
+		return
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if h.closed {
+		return
+	}
+
+	select {
+	case h.queue <- records:
+	default:
+		logger.L.Warn("price history queue is full, dropping records",
+			zap.Int("records", len(records)),
+		)
+	}
+}
+
+// Close дожидается записи всего, что уже стоит в очереди.
+func (h *PriceHistory) Close() {
+	h.mu.Lock()
+	if !h.closed {
+		h.closed = true
+		close(h.queue)
+	}
+	h.mu.Unlock()
+
+	h.wg.Wait()
+}
+
+func (h *PriceHistory) writer() {
+	defer h.wg.Done()
+
+	for records := range h.queue {
+		if err := h.insert(records); err != nil {
+			logger.L.Error("price history write failed",
+				zap.Int("records", len(records)),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+func (h *PriceHistory) insert(records []priceRecord) error {
+	tx, err := h.db.sql.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`INSERT INTO price_history
+		(mpn, mpn_norm, seller_name, source, quantity, price, currency, stock, recorded_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, r := range records {
+		if _, err := stmt.Exec(
+			r.MPN,
+			NormalizeMPN(r.MPN),
+			r.SellerName,
+			r.Source,
+			r.Quantity,
+			r.Price,
+			r.Currency,
+			r.Stock,
+			r.RecordedAt.Unix(),
+		); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Series возвращает временные ряды цен и склада по каждому продавцу для MPN.
+func (h *PriceHistory) Series(ctx context.Context, mpn string, from, to time.Time) ([]types.SellerPriceHistory, error) {
+	query := `SELECT seller_name, source, mpn, quantity, price, currency, stock, recorded_at
+		FROM price_history
+		WHERE mpn_norm = ?`
+	args := []interface{}{NormalizeMPN(mpn)}
+
+	if !from.IsZero() {
+		query += ` AND recorded_at >= ?`
+		args = append(args, from.Unix())
+	}
+	if !to.IsZero() {
+		query += ` AND recorded_at <= ?`
+		args = append(args, to.Unix())
+	}
+
+	query += ` ORDER BY seller_name, source, recorded_at, quantity`
+
+	rows, err := h.db.sql.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query price history: %w", err)
+	}
+	defer rows.Close()
+
+	var result []types.SellerPriceHistory
+	index := map[string]int{}
+
+	for rows.Next() {
+		var (
+			seller, source string
+			point          types.PriceHistoryPoint
+			recordedAt     int64
+		)
+
+		if err := rows.Scan(&seller, &source, &point.MPN, &point.Quantity, &point.Price, &point.Currency, &point.Stock, &recordedAt); err != nil {
+			return nil, fmt.Errorf("scan price history: %w", err)
+		}
+		point.Timestamp = time.Unix(recordedAt, 0).UTC()
+
+		key := source + "|" + seller
+		i, ok := index[key]
+		if !ok {
+			i = len(result)
+			index[key] = i
+			result = append(result, types.SellerPriceHistory{
+				SellerName: seller,
+				Source:     source,
+			})
+		}
+
+		result[i].Points = append(result[i].Points, point)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("read price history: %w", err)
+	}
+
+	return result, nil
+}
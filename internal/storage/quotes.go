@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"dynamic-pricing-tool-ru/internal/types"
+)
+
+type Quotes struct {
+	db *DB
+}
+
+func NewQuotes(db *DB) *Quotes {
+	return &Quotes{db: db}
+}
+
+// Create сохраняет КП и присваивает ему номер вида Q-20240131-0042.
+func (q *Quotes) Create(ctx context.Context, quote types.Quote) (types.Quote, error) {
+	items, err := json.Marshal(quote.Items)
+	if err != nil {
+		return quote, fmt.Errorf("encode quote items: %w", err)
+	}
+
+	tx, err := q.db.sql.BeginTx(ctx, nil)
+	if err != nil {
+		return quote, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `INSERT INTO quotes
+		(customer, note, items, created_at, valid_until)
+		VALUES (?, ?, ?, ?, ?)`,
+		quote.Customer,
+		quote.Note,
+		string(items),
+		quote.CreatedAt.Unix(),
+		quote.ValidUntil.Unix(),
+	)
+	if err != nil {
+		return quote, fmt.Errorf("insert quote: %w", err)
+	}
+
+	quote.ID, err = res.LastInsertId()
+	if err != nil {
+		return quote, fmt.Errorf("insert quote: %w", err)
+	}
+
+	quote.Number = fmt.Sprintf("Q-%s-%04d", quote.CreatedAt.Format("20060102"), quote.ID)
+
+	if _, err := tx.ExecContext(ctx, `UPDATE quotes SET number = ? WHERE id = ?`, quote.Number, quote.ID); err != nil {
+		return quote, fmt.Errorf("assign quote number: %w", err)
+	}
+
+	return quote, tx.Commit()
+}
+
+// Get ищет КП по номеру среди КП клиента customerID. Для чужого номера
+// ответ тот же, что и для несуществующего.
+func (q *Quotes) Get(ctx context.Context, number, customerID string) (types.Quote, error) {
+	var (
+		quote                 types.Quote
+		items                 string
+		createdAt, validUntil int64
+	)
+
+	err := q.db.sql.QueryRowContext(ctx, `SELECT id, number, customer, note, items, created_at, valid_until
+		FROM quotes WHERE number = ? AND customer = ?`, number, customerID).
+		Scan(&quote.ID, &quote.Number, &quote.Customer, &quote.Note, &items, &createdAt, &validUntil)
+	if errors.Is(err, sql.ErrNoRows) {
+		return quote, ErrNotFound
+	}
+	if err != nil {
+		return quote, fmt.Errorf("query quote: %w", err)
+	}
+
+	if err := json.Unmarshal([]byte(items), &quote.Items); err != nil {
+		return quote, fmt.Errorf("decode quote items: %w", err)
+	}
+
+	quote.CreatedAt = time.Unix(createdAt, 0).UTC()
+	quote.ValidUntil = time.Unix(validUntil, 0).UTC()
+
+	return quote, nil
+}
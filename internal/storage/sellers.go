@@ -0,0 +1,140 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"dynamic-pricing-tool-ru/internal/types"
+)
+
+type SellerRules struct {
+	db *DB
+}
+
+func NewSellerRules(db *DB) *SellerRules {
+	return &SellerRules{db: db}
+}
+
+// NormalizeSeller — ключ сравнения названий продавцов: регистр и лишние
+// пробелы в выдаче поставщиков не совпадают от запроса к запросу.
+func NormalizeSeller(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// Save создаёт правило при ID = 0 и обновляет существующее иначе.
+func (s *SellerRules) Save(ctx context.Context, rule types.SellerRule) (types.SellerRule, error) {
+	rule.Source = strings.ToLower(strings.TrimSpace(rule.Source))
+	rule.SellerName = strings.TrimSpace(rule.SellerName)
+	rule.UpdatedAt = time.Now().UTC()
+
+	var trust sql.NullInt64
+	if rule.TrustScore != nil {
+		trust = sql.NullInt64{Int64: int64(*rule.TrustScore), Valid: true}
+	}
+
+	args := []interface{}{
+		rule.Source,
+		rule.SellerName,
+		NormalizeSeller(rule.SellerName),
+		rule.StockID,
+		rule.Action,
+		trust,
+		rule.Note,
+		rule.UpdatedAt.Unix(),
+	}
+
+	if rule.ID == 0 {
+		res, err := s.db.sql.ExecContext(ctx, `INSERT INTO seller_rules
+			(source, seller_name, seller_norm, stock_id, action, trust_score, note, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`, args...)
+		if err != nil {
+			return rule, fmt.Errorf("insert seller rule: %w", err)
+		}
+
+		rule.ID, err = res.LastInsertId()
+		if err != nil {
+			return rule, fmt.Errorf("insert seller rule: %w", err)
+		}
+
+		return rule, nil
+	}
+
+	res, err := s.db.sql.ExecContext(ctx, `UPDATE seller_rules SET
+		source = ?, seller_name = ?, seller_norm = ?, stock_id = ?, action = ?,
+		trust_score = ?, note = ?, updated_at = ?
+		WHERE id = ?`, append(args, rule.ID)...)
+	if err != nil {
+		return rule, fmt.Errorf("update seller rule: %w", err)
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return rule, ErrNotFound
+	}
+
+	return rule, nil
+}
+
+func (s *SellerRules) Get(ctx context.Context, id int64) (types.SellerRule, error) {
+	rules, err := s.query(ctx, `WHERE id = ?`, id)
+	if err != nil {
+		return types.SellerRule{}, err
+	}
+	if len(rules) == 0 {
+		return types.SellerRule{}, ErrNotFound
+	}
+	return rules[0], nil
+}
+
+func (s *SellerRules) List(ctx context.Context) ([]types.SellerRule, error) {
+	return s.query(ctx, `ORDER BY id`)
+}
+
+func (s *SellerRules) Delete(ctx context.Context, id int64) error {
+	res, err := s.db.sql.ExecContext(ctx, `DELETE FROM seller_rules WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("delete seller rule: %w", err)
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *SellerRules) query(ctx context.Context, where string, args ...interface{}) ([]types.SellerRule, error) {
+	rows, err := s.db.sql.QueryContext(ctx, `SELECT
+		id, source, seller_name, stock_id, action, trust_score, note, updated_at
+		FROM seller_rules `+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query seller rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []types.SellerRule
+	for rows.Next() {
+		var (
+			rule      types.SellerRule
+			trust     sql.NullInt64
+			updatedAt int64
+		)
+
+		if err := rows.Scan(&rule.ID, &rule.Source, &rule.SellerName, &rule.StockID,
+			&rule.Action, &trust, &rule.Note, &updatedAt); err != nil {
+			return nil, fmt.Errorf("scan seller rules: %w", err)
+		}
+
+		if trust.Valid {
+			score := int(trust.Int64)
+			rule.TrustScore = &score
+		}
+		rule.UpdatedAt = time.Unix(updatedAt, 0).UTC()
+
+		rules = append(rules, rule)
+	}
+
+	return rules, rows.Err()
+}
@@ -0,0 +1,165 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	_ "modernc.org/sqlite"
+)
+
+// DB — встроенная SQLite база сервиса. Драйвер на чистом Go, поэтому сборка
+// с CGO_ENABLED=0 продолжает работать.
+type DB struct {
+	sql *sql.DB
+}
+
+// migrations применяются по порядку, номер последней применённой хранится
+// в PRAGMA user_version. Существующие шаги не меняются — только дописываются.
+var migrations = []string{
+	`CREATE TABLE IF NOT EXISTS price_history (
+		id           INTEGER PRIMARY KEY AUTOINCREMENT,
+		mpn          TEXT    NOT NULL,
+		mpn_norm     TEXT    NOT NULL,
+		seller_name  TEXT    NOT NULL,
+		source       TEXT    NOT NULL,
+		quantity     INTEGER NOT NULL,
+		price        REAL    NOT NULL,
+		currency     TEXT    NOT NULL,
+		stock        INTEGER NOT NULL,
+		recorded_at  INTEGER NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_price_history_mpn
+		ON price_history (mpn_norm, recorded_at)`,
+	`CREATE TABLE IF NOT EXISTS watchlist (
+		id                INTEGER PRIMARY KEY AUTOINCREMENT,
+		mpn               TEXT    NOT NULL,
+		mpn_norm          TEXT    NOT NULL,
+		quantity          INTEGER NOT NULL,
+		price_drop_pct    REAL    NOT NULL DEFAULT 0,
+		min_stock         INTEGER NOT NULL DEFAULT 0,
+		notify_new_seller INTEGER NOT NULL DEFAULT 0,
+		state             TEXT    NOT NULL DEFAULT '',
+		created_at        INTEGER NOT NULL,
+		checked_at        INTEGER NOT NULL DEFAULT 0
+	)`,
+	`CREATE TABLE IF NOT EXISTS quotes (
+		id          INTEGER PRIMARY KEY AUTOINCREMENT,
+		number      TEXT    NOT NULL DEFAULT '',
+		customer    TEXT    NOT NULL DEFAULT '',
+		note        TEXT    NOT NULL DEFAULT '',
+		items       TEXT    NOT NULL,
+		created_at  INTEGER NOT NULL,
+		valid_until INTEGER NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_quotes_number ON quotes (number)`,
+	`CREATE TABLE IF NOT EXISTS customers (
+		id                 TEXT    PRIMARY KEY,
+		name               TEXT    NOT NULL DEFAULT '',
+		api_key_hash       TEXT    NOT NULL DEFAULT '',
+		markup_coef        REAL    NOT NULL DEFAULT 0,
+		currency           TEXT    NOT NULL DEFAULT '',
+		include_vat        INTEGER NOT NULL DEFAULT 0,
+		excluded_suppliers TEXT    NOT NULL DEFAULT '[]',
+		updated_at         INTEGER NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_customers_api_key ON customers (api_key_hash)`,
+	`ALTER TABLE customers ADD COLUMN rate_limit_per_minute INTEGER NOT NULL DEFAULT 0`,
+	`ALTER TABLE customers ADD COLUMN daily_row_quota INTEGER NOT NULL DEFAULT 0`,
+	`CREATE TABLE IF NOT EXISTS api_usage (
+		customer_id TEXT    NOT NULL,
+		day         TEXT    NOT NULL,
+		requests    INTEGER NOT NULL DEFAULT 0,
+		rows        INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (customer_id, day)
+	)`,
+	`CREATE TABLE IF NOT EXISTS cross_references (
+		mpn_norm         TEXT    NOT NULL,
+		alternative_norm TEXT    NOT NULL,
+		mpn              TEXT    NOT NULL,
+		alternative      TEXT    NOT NULL,
+		compatibility    TEXT    NOT NULL DEFAULT '',
+		note             TEXT    NOT NULL DEFAULT '',
+		updated_at       INTEGER NOT NULL,
+		PRIMARY KEY (mpn_norm, alternative_norm)
+	)`,
+	`CREATE TABLE IF NOT EXISTS seller_rules (
+		id          INTEGER PRIMARY KEY AUTOINCREMENT,
+		source      TEXT    NOT NULL DEFAULT '',
+		seller_name TEXT    NOT NULL DEFAULT '',
+		seller_norm TEXT    NOT NULL DEFAULT '',
+		stock_id    INTEGER NOT NULL DEFAULT 0,
+		action      TEXT    NOT NULL DEFAULT '',
+		trust_score INTEGER,
+		note        TEXT    NOT NULL DEFAULT '',
+		updated_at  INTEGER NOT NULL
+	)`,
+	`ALTER TABLE watchlist ADD COLUMN customer_id TEXT NOT NULL DEFAULT ''`,
+	`CREATE INDEX IF NOT EXISTS idx_watchlist_customer ON watchlist (customer_id)`,
+	`CREATE INDEX IF NOT EXISTS idx_quotes_customer ON quotes (customer, number)`,
+	// ключ должен однозначно указывать на клиента: совпавшие ключи
+	// сбрасываются, таким клиентам нужно выдать новые
+	`UPDATE customers SET api_key_hash = '' WHERE api_key_hash IN (
+		SELECT api_key_hash FROM customers WHERE api_key_hash != ''
+		GROUP BY api_key_hash HAVING COUNT(*) > 1
+	)`,
+	`DROP INDEX IF EXISTS idx_customers_api_key`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS idx_customers_api_key ON customers (api_key_hash) WHERE api_key_hash != ''`,
+}
+
+func Open(path string) (*DB, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("create db dir: %w", err)
+	}
+
+	db, err := sql.Open("sqlite", path+"?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, fmt.Errorf("open db: %w", err)
+	}
+
+	// SQLite допускает одного писателя, лишние соединения дают SQLITE_BUSY
+	db.SetMaxOpenConns(1)
+
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &DB{sql: db}, nil
+}
+
+func migrate(db *sql.DB) error {
+	var version int
+	if err := db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		return fmt.Errorf("read schema version: %w", err)
+	}
+
+	for i := version; i < len(migrations); i++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return fmt.Errorf("migrate db: %w", err)
+		}
+
+		if _, err := tx.Exec(migrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migrate db to version %d: %w", i+1, err)
+		}
+
+		// PRAGMA не принимает параметры, номер подставляется как число
+		if _, err := tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, i+1)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migrate db to version %d: %w", i+1, err)
+		}
+
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("migrate db to version %d: %w", i+1, err)
+		}
+	}
+
+	return nil
+}
+
+func (d *DB) Close() error {
+	return d.sql.Close()
+}
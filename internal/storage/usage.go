@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"dynamic-pricing-tool-ru/internal/types"
+)
+
+// Usage — счётчики запросов и строк BOM по клиентам за сутки.
+type Usage struct {
+	db *DB
+}
+
+func NewUsage(db *DB) *Usage {
+	return &Usage{db: db}
+}
+
+func usageDay(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+func (u *Usage) RecordRequest(ctx context.Context, customerID string) error {
+	_, err := u.db.sql.ExecContext(ctx, `INSERT INTO api_usage (customer_id, day, requests)
+		VALUES (?, ?, 1)
+		ON CONFLICT (customer_id, day) DO UPDATE SET requests = requests + 1`,
+		customerID, usageDay(time.Now()))
+	if err != nil {
+		return fmt.Errorf("record usage: %w", err)
+	}
+	return nil
+}
+
+// ConsumeRows списывает rows из дневной квоты. Если квота превышена,
+// ничего не списывается и возвращается ok=false с уже израсходованным объёмом.
+// quota <= 0 означает отсутствие ограничения.
+func (u *Usage) ConsumeRows(ctx context.Context, customerID string, rows, quota int) (used int, ok bool, err error) {
+	tx, err := u.db.sql.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, false, err
+	}
+	defer tx.Rollback()
+
+	day := usageDay(time.Now())
+
+	err = tx.QueryRowContext(ctx, `SELECT rows FROM api_usage WHERE customer_id = ? AND day = ?`,
+		customerID, day).Scan(&used)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, false, fmt.Errorf("query usage: %w", err)
+	}
+
+	if quota > 0 && used+rows > quota {
+		return used, false, nil
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO api_usage (customer_id, day, rows)
+		VALUES (?, ?, ?)
+		ON CONFLICT (customer_id, day) DO UPDATE SET rows = rows + excluded.rows`,
+		customerID, day, rows)
+	if err != nil {
+		return used, false, fmt.Errorf("update usage: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return used, false, err
+	}
+
+	return used + rows, true, nil
+}
+
+func (u *Usage) History(ctx context.Context, customerID string, days int) ([]types.UsageDay, error) {
+	since := usageDay(time.Now().AddDate(0, 0, -days+1))
+
+	rows, err := u.db.sql.QueryContext(ctx, `SELECT day, requests, rows FROM api_usage
+		WHERE customer_id = ? AND day >= ? ORDER BY day`, customerID, since)
+	if err != nil {
+		return nil, fmt.Errorf("query usage: %w", err)
+	}
+	defer rows.Close()
+
+	var result []types.UsageDay
+	for rows.Next() {
+		var d types.UsageDay
+		if err := rows.Scan(&d.Day, &d.Requests, &d.Rows); err != nil {
+			return nil, fmt.Errorf("scan usage: %w", err)
+		}
+		result = append(result, d)
+	}
+
+	return result, rows.Err()
+}
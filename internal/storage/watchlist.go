@@ -0,0 +1,171 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"dynamic-pricing-tool-ru/internal/types"
+)
+
+var ErrNotFound = errors.New("not found")
+
+// ErrConflict — запись нарушает уникальность, например повторяет чужой ключ API.
+var ErrConflict = errors.New("conflict")
+
+// WatchState — снимок офферов на момент последней проверки, с которым
+// сравнивается следующий опрос поставщиков.
+type WatchState struct {
+	Sellers    map[string]WatchSellerState `json:"sellers"`
+	TotalStock int                         `json:"total_stock"`
+}
+
+type WatchSellerState struct {
+	SellerName string  `json:"seller_name"`
+	Source     string  `json:"source"`
+	Price      float64 `json:"price"`
+	Currency   string  `json:"currency"`
+	Stock      int     `json:"stock"`
+	// BaselinePrice — цена, от которой считается падение: запоминается при
+	// первом появлении продавца и сбрасывается только после уведомления,
+	// чтобы медленное снижение тоже дошло до порога
+	BaselinePrice float64 `json:"baseline_price,omitempty"`
+	// Missed — сколько последних проверок подряд продавца не было в выдаче
+	Missed int `json:"missed,omitempty"`
+}
+
+type Watchlist struct {
+	db *DB
+}
+
+func NewWatchlist(db *DB) *Watchlist {
+	return &Watchlist{db: db}
+}
+
+func (w *Watchlist) Add(ctx context.Context, item types.WatchItem) (types.WatchItem, error) {
+	if item.Quantity <= 0 {
+		item.Quantity = 1
+	}
+	item.CreatedAt = time.Now().UTC()
+
+	res, err := w.db.sql.ExecContext(ctx, `INSERT INTO watchlist
+		(customer_id, mpn, mpn_norm, quantity, price_drop_pct, min_stock, notify_new_seller, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		item.CustomerID,
+		item.MPN,
+		NormalizeMPN(item.MPN),
+		item.Quantity,
+		item.PriceDropPercent,
+		item.MinStock,
+		item.NotifyNewSeller,
+		item.CreatedAt.Unix(),
+	)
+	if err != nil {
+		return item, fmt.Errorf("insert watch: %w", err)
+	}
+
+	item.ID, err = res.LastInsertId()
+	if err != nil {
+		return item, fmt.Errorf("insert watch: %w", err)
+	}
+
+	return item, nil
+}
+
+// Delete удаляет позицию клиента; чужая позиция считается несуществующей.
+func (w *Watchlist) Delete(ctx context.Context, id int64, customerID string) error {
+	res, err := w.db.sql.ExecContext(ctx, `DELETE FROM watchlist WHERE id = ? AND customer_id = ?`, id, customerID)
+	if err != nil {
+		return fmt.Errorf("delete watch: %w", err)
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// List возвращает все позиции — для планировщика проверок.
+func (w *Watchlist) List(ctx context.Context) ([]types.WatchItem, error) {
+	return w.list(ctx, ``)
+}
+
+// ListByCustomer возвращает позиции одного клиента.
+func (w *Watchlist) ListByCustomer(ctx context.Context, customerID string) ([]types.WatchItem, error) {
+	return w.list(ctx, `WHERE customer_id = ?`, customerID)
+}
+
+func (w *Watchlist) list(ctx context.Context, where string, args ...any) ([]types.WatchItem, error) {
+	rows, err := w.db.sql.QueryContext(ctx, `SELECT
+		id, customer_id, mpn, quantity, price_drop_pct, min_stock, notify_new_seller, created_at, checked_at
+		FROM watchlist `+where+` ORDER BY id`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query watchlist: %w", err)
+	}
+	defer rows.Close()
+
+	var items []types.WatchItem
+	for rows.Next() {
+		var (
+			item                 types.WatchItem
+			createdAt, checkedAt int64
+		)
+
+		if err := rows.Scan(&item.ID, &item.CustomerID, &item.MPN, &item.Quantity, &item.PriceDropPercent,
+			&item.MinStock, &item.NotifyNewSeller, &createdAt, &checkedAt); err != nil {
+			return nil, fmt.Errorf("scan watchlist: %w", err)
+		}
+
+		item.CreatedAt = time.Unix(createdAt, 0).UTC()
+		if checkedAt > 0 {
+			t := time.Unix(checkedAt, 0).UTC()
+			item.CheckedAt = &t
+		}
+
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// State возвращает nil, если позиция ещё ни разу не проверялась.
+func (w *Watchlist) State(ctx context.Context, id int64) (*WatchState, error) {
+	var raw string
+	err := w.db.sql.QueryRowContext(ctx, `SELECT state FROM watchlist WHERE id = ?`, id).Scan(&raw)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("query watch state: %w", err)
+	}
+
+	if raw == "" {
+		return nil, nil
+	}
+
+	var state WatchState
+	if err := json.Unmarshal([]byte(raw), &state); err != nil {
+		return nil, fmt.Errorf("decode watch state: %w", err)
+	}
+
+	return &state, nil
+}
+
+func (w *Watchlist) SaveState(ctx context.Context, id int64, state *WatchState) error {
+	raw, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("encode watch state: %w", err)
+	}
+
+	_, err = w.db.sql.ExecContext(ctx, `UPDATE watchlist SET state = ?, checked_at = ? WHERE id = ?`,
+		string(raw), time.Now().UTC().Unix(), id)
+	if err != nil {
+		return fmt.Errorf("update watch state: %w", err)
+	}
+
+	return nil
+}
@@ -1,17 +1,47 @@
 package types
 
+import "time"
+
 type Request struct {
 	Mapping map[string]string `json:"mapping"`
 	Data    [][]string        `json:"data"`
 	Mode    string            `json:"mode"`
+	// Customer — ID профиля клиента, если запрос пришёл без X-API-Key
+	Customer string `json:"customer"`
+	// SkipInvalid — обработать корректные строки, а ошибочные вернуть в problems
+	SkipInvalid bool `json:"skip_invalid"`
 }
 
 type PartData struct {
 	PartNumber string
 	Quantity   string
+	Qty        int
 	RowIndex   int
 }
 
+type RowProblem struct {
+	Row      int    `json:"row"`
+	Field    string `json:"field,omitempty"`
+	Code     string `json:"code"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+	Value    string `json:"value,omitempty"`
+}
+
+type ValidationResult struct {
+	Parts    []PartData   `json:"-"`
+	Problems []RowProblem `json:"problems"`
+}
+
+func (v *ValidationResult) HasErrors() bool {
+	for _, p := range v.Problems {
+		if p.Severity == "error" {
+			return true
+		}
+	}
+	return false
+}
+
 type GetchipsResponse struct {
 	Data []struct {
 		Title         string       `json:"title"`
@@ -134,22 +164,53 @@ type APIResponse struct {
 	RowIndex     int
 }
 
+// AnalysisResult — сводка по обработанной BOM. Стоимости посчитаны по
+// ценам поставщиков при запрошенном количестве и приведены к Currency.
 type AnalysisResult struct {
-	TotalParts       int               `json:"totalParts"`
-	GetchipsSuccess  int               `json:"getchipsSuccess"`
-	GetchipsInStock  int               `json:"getchipsInStock"`
-	EfindSuccess     int               `json:"efindSuccess"`
-	EfindInStock     int               `json:"efindInStock"`
-	BothAPIsSuccess  int               `json:"bothAPIsSuccess"`
-	PriceComparisons []PriceComparison `json:"priceComparisons,omitempty"`
+	TotalRows         int   `json:"total_rows"`
+	RowsWithOffers    int   `json:"rows_with_offers"`
+	RowsWithoutOffers []int `json:"rows_without_offers"`
+
+	Currency string `json:"currency"`
+	// BestPriceTotal — стоимость BOM, если по каждой строке взять самое
+	// дешёвое предложение любого поставщика
+	BestPriceTotal float64 `json:"best_price_total"`
+	// PricedRows — строки, для которых нашлась хотя бы одна цена
+	PricedRows int `json:"priced_rows"`
+
+	Suppliers []SupplierAnalysis `json:"suppliers"`
+
+	// Unconverted — офферы с ценой, которую нечем привести к Currency (нет
+	// курса валюты); в стоимости и выигрыши они не входят
+	Unconverted []UnconvertedOffer `json:"unconverted,omitempty"`
 }
 
-type PriceComparison struct {
-	PartNumber        string  `json:"partNumber"`
-	GetchipsPrice     float64 `json:"getchipsPrice"`
-	EfindPrice        float64 `json:"efindPrice"`
-	BetterPrice       string  `json:"betterPrice"`
-	DifferencePercent float64 `json:"differencePercent"`
+type UnconvertedOffer struct {
+	Row        int     `json:"row"`
+	MPN        string  `json:"mpn"`
+	Source     string  `json:"source"`
+	SellerName string  `json:"seller_name"`
+	Price      float64 `json:"price"`
+	Currency   string  `json:"currency"`
+}
+
+type SupplierAnalysis struct {
+	Source string `json:"source"`
+	// RowsCovered — строки, по которым у поставщика есть хотя бы одно предложение
+	RowsCovered  int     `json:"rows_covered"`
+	CoverageRate float64 `json:"coverage_rate"`
+	// RowsInStock — строки, где запрошенное количество есть на складе поставщика
+	RowsInStock int     `json:"rows_in_stock"`
+	InStockRate float64 `json:"in_stock_rate"`
+	// TotalCost — стоимость строк, которые поставщик может оценить, если
+	// покупать только у него; PricedRows показывает, сколько их
+	TotalCost  float64 `json:"total_cost"`
+	PricedRows int     `json:"priced_rows"`
+	// Wins — строки, где у поставщика самая низкая цена
+	Wins int `json:"wins"`
+	// UnconvertedOffers — офферы, не попавшие в TotalCost и Wins из-за
+	// валюты без курса; сами офферы перечислены в AnalysisResult.Unconverted
+	UnconvertedOffers int `json:"unconverted_offers,omitempty"`
 }
 
 // ================= PROMELEC =================
@@ -169,19 +230,22 @@ type PromelecItem struct {
 	Moq          int    `json:"moq"`
 	Munit        string `json:"munit"`
 	DeliveryTime string `json:"delivery_time"`
-	Pricebreaks  []struct {
-		Quant int     `json:"quant"`
-		Price float64 `json:"price"`
-	} `json:"pricebreaks"`
-	Vendors []struct {
-		Vendor      int `json:"vendor"`
-		Quant       int `json:"quant`
-		Delivery    int `json:"delivery"`
-		PriceBreaks []struct {
-			Quant int     `json:"quant"`
-			Price float64 `json:"price"`
-		}
-	}
+
+	Pricebreaks []PromelecPriceBreak `json:"pricebreaks"`
+	Vendors     []PromelecVendor     `json:"vendors"`
+}
+
+// PromelecVendor — предложение стороннего склада по той же позиции.
+type PromelecVendor struct {
+	Vendor      int                  `json:"vendor"`
+	Quant       int                  `json:"quant"`
+	Delivery    int                  `json:"delivery"`
+	PriceBreaks []PromelecPriceBreak `json:"pricebreaks"`
+}
+
+type PromelecPriceBreak struct {
+	Quant int     `json:"quant"`
+	Price float64 `json:"price"`
 }
 
 type SimplifiedPromelecData struct {
@@ -193,12 +257,47 @@ type SimplifiedPromelecData struct {
 	Prices       []PriceBreak
 }
 
+// PartView — строка BOM с атрибутами детали, сводкой по предложениям и
+// самими предложениями; ответ /process?view=parts.
+type PartView struct {
+	Row          int    `json:"row"`
+	RequestedMPN string `json:"requested_mpn"`
+	RequestedQty int    `json:"requested_quantity"`
+
+	MPN          string `json:"mpn,omitempty"`
+	Manufacturer string `json:"manufacturer,omitempty"`
+	Description  string `json:"description,omitempty"`
+	CategoryID   int    `json:"category_id,omitempty"`
+	CategoryName string `json:"category_name,omitempty"`
+	Package      string `json:"package,omitempty"`
+	ImageURL     string `json:"image_url,omitempty"`
+
+	Summary PartSummary    `json:"summary"`
+	Offers  []UnifiedOffer `json:"offers"`
+	// Alternatives — предложения по аналогам; в сводку не входят
+	Alternatives []UnifiedOffer `json:"alternatives,omitempty"`
+}
+
+// PartSummary — цены поставщиков за штуку при запрошенном количестве,
+// приведённые к Currency.
+type PartSummary struct {
+	Currency        string  `json:"currency"`
+	MinPrice        float64 `json:"min_price"`
+	MedianPrice     float64 `json:"median_price"`
+	TotalStock      int     `json:"total_stock"`
+	Sellers         int     `json:"sellers"`
+	Offers          int     `json:"offers"`
+	FastestDelivery string  `json:"fastest_delivery,omitempty"`
+}
+
 type UnifiedPriceBreak struct {
 	Quantity              int     `json:"quantity"`
 	Price                 float64 `json:"price"`
 	CostWithDelivery      float64 `json:"cost_with_delivery"`
 	TargetPricePurchasing float64 `json:"target_price_purchasing"`
 	TargetPriceSales      float64 `json:"target_price_sales"`
+	TargetPriceSalesNet   float64 `json:"target_price_sales_net"`
+	TargetPriceSalesGross float64 `json:"target_price_sales_gross"`
 	Currency              string  `json:"currency"`
 }
 
@@ -212,18 +311,214 @@ type UnifiedOffer struct {
 	Manufacturer string `json:"manufacturer"`
 	Description  string `json:"description,omitempty"`
 	ImageURL     string `json:"image_url,omitempty"`
+	Package      string `json:"package,omitempty"`
+	// Packaging — упаковка конкретного предложения (катушка, лента), а не корпус
+	Packaging string `json:"packaging,omitempty"`
 
 	SellerName     string `json:"seller_name"`
 	SellerHomepage string `json:"seller_homepageUrl,omitempty"`
 	SellerVerified bool   `json:"seller_verified"`
+	// SellerStockID — ID склада продавца у Efind
+	SellerStockID int    `json:"seller_stock_id,omitempty"`
+	TrustScore    *int   `json:"trust_score,omitempty"`
+	SellerNote    string `json:"seller_note,omitempty"`
 
 	Stock  int    `json:"stock"`
 	Status string `json:"status"`
 
-	Price        float64             `json:"price"`
-	Currency     string              `json:"currency"`
-	DeliveryTime string              `json:"delivery_time"`
-	PriceBreaks  []UnifiedPriceBreak `json:"priceBreaks"`
+	Price            float64             `json:"price"`
+	Currency         string              `json:"currency"`
+	PriceIncludesVAT bool                `json:"price_includes_vat"`
+	DeliveryTime     string              `json:"delivery_time"`
+	PriceBreaks      []UnifiedPriceBreak `json:"priceBreaks"`
 
 	Source string `json:"source"`
+
+	// Substitute заполнен у предложений на замену из таблицы аналогов;
+	// у таких офферов MPN — аналог, RequestedMPN — исходная строка BOM
+	Substitute *Substitute `json:"substitute,omitempty"`
+}
+
+type Substitute struct {
+	For           string `json:"for"`
+	Compatibility string `json:"compatibility,omitempty"`
+	Note          string `json:"note,omitempty"`
+}
+
+// ================= HISTORY =================
+
+type PriceHistoryPoint struct {
+	Timestamp time.Time `json:"timestamp"`
+	MPN       string    `json:"mpn"`
+	Quantity  int       `json:"quantity"`
+	Price     float64   `json:"price"`
+	Currency  string    `json:"currency"`
+	Stock     int       `json:"stock"`
+}
+
+type SellerPriceHistory struct {
+	SellerName string              `json:"seller_name"`
+	Source     string              `json:"source"`
+	Points     []PriceHistoryPoint `json:"points"`
+}
+
+// ================= CROSS-REFERENCE =================
+
+// CrossReference — аналог детали из локальной таблицы. Связь направленная:
+// Alternative может заменить MPN, но не обязательно наоборот.
+type CrossReference struct {
+	MPN         string `json:"mpn" binding:"required"`
+	Alternative string `json:"alternative" binding:"required"`
+	// Compatibility — степень совместимости: drop-in, pin-compatible, functional и т.п.
+	Compatibility string    `json:"compatibility,omitempty"`
+	Note          string    `json:"note,omitempty"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// ================= SELLERS =================
+
+// SellerRule — правило для продавца, найденного по StockID (склад Efind)
+// или по названию. Source ограничивает правило одним поставщиком.
+type SellerRule struct {
+	ID         int64  `json:"id"`
+	Source     string `json:"source,omitempty"`
+	SellerName string `json:"seller_name,omitempty"`
+	StockID    int    `json:"stock_id,omitempty"`
+	// Action — allow (проверенный продавец), block (офферы скрываются) или пусто
+	Action string `json:"action,omitempty"`
+	// TrustScore — доверие от 0 до 100, влияет на порядок офферов
+	TrustScore *int      `json:"trust_score,omitempty"`
+	Note       string    `json:"note,omitempty"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// ================= WATCHLIST =================
+
+type WatchItem struct {
+	ID int64 `json:"id"`
+	// CustomerID — владелец позиции; проверки списываются с его квоты
+	CustomerID       string     `json:"customer_id,omitempty"`
+	MPN              string     `json:"mpn" binding:"required"`
+	Quantity         int        `json:"quantity"`
+	PriceDropPercent float64    `json:"price_drop_pct"`
+	MinStock         int        `json:"min_stock"`
+	NotifyNewSeller  bool       `json:"notify_new_seller"`
+	CreatedAt        time.Time  `json:"created_at"`
+	CheckedAt        *time.Time `json:"checked_at,omitempty"`
+}
+
+type WatchAlert struct {
+	Type       string  `json:"type"`
+	MPN        string  `json:"mpn"`
+	SellerName string  `json:"seller_name,omitempty"`
+	Source     string  `json:"source,omitempty"`
+	OldPrice   float64 `json:"old_price,omitempty"`
+	NewPrice   float64 `json:"new_price,omitempty"`
+	Currency   string  `json:"currency,omitempty"`
+	Stock      int     `json:"stock"`
+	Threshold  float64 `json:"threshold,omitempty"`
+}
+
+type WatchNotification struct {
+	WatchID   int64        `json:"watch_id"`
+	MPN       string       `json:"mpn"`
+	Alerts    []WatchAlert `json:"alerts"`
+	CheckedAt time.Time    `json:"checked_at"`
+}
+
+// ================= QUOTES =================
+
+type QuoteRequest struct {
+	Customer  string             `json:"customer"`
+	Note      string             `json:"note"`
+	ValidDays int                `json:"valid_days"`
+	Items     []QuoteRequestItem `json:"items" binding:"required,min=1,dive"`
+}
+
+// QuoteRequestItem — позиция КП. Цены клиент не передаёт: сервер заново
+// опрашивает поставщиков. Source и SellerName только выбирают одно из
+// найденных предложений; без них берётся самое дешёвое.
+type QuoteRequestItem struct {
+	MPN        string `json:"mpn" binding:"required"`
+	Quantity   int    `json:"quantity" binding:"required,min=1"`
+	Source     string `json:"source,omitempty"`
+	SellerName string `json:"seller_name,omitempty"`
+}
+
+type QuoteItem struct {
+	Offer     UnifiedOffer `json:"offer"`
+	Quantity  int          `json:"quantity"`
+	UnitPrice float64      `json:"unit_price"`
+	Total     float64      `json:"total"`
+	Currency  string       `json:"currency"`
+}
+
+type Quote struct {
+	ID         int64              `json:"id"`
+	Number     string             `json:"number"`
+	Customer   string             `json:"customer,omitempty"`
+	Note       string             `json:"note,omitempty"`
+	Items      []QuoteItem        `json:"items"`
+	Totals     map[string]float64 `json:"totals"`
+	CreatedAt  time.Time          `json:"created_at"`
+	ValidUntil time.Time          `json:"valid_until"`
+	Expired    bool               `json:"expired"`
+}
+
+type QuoteItemDiff struct {
+	MPN               string  `json:"mpn"`
+	SellerName        string  `json:"seller_name"`
+	Source            string  `json:"source"`
+	Quantity          int     `json:"quantity"`
+	Currency          string  `json:"currency"`
+	QuotedUnitPrice   float64 `json:"quoted_unit_price"`
+	CurrentUnitPrice  float64 `json:"current_unit_price,omitempty"`
+	Difference        float64 `json:"difference,omitempty"`
+	DifferencePercent float64 `json:"difference_percent,omitempty"`
+	QuotedStock       int     `json:"quoted_stock"`
+	CurrentStock      int     `json:"current_stock"`
+	Status            string  `json:"status"`
+}
+
+// ================= CUSTOMERS =================
+
+type CustomerProfile struct {
+	ID                string   `json:"id"`
+	Name              string   `json:"name"`
+	APIKey            string   `json:"api_key,omitempty"`
+	MarkupCoef        float64  `json:"markup_coef"`
+	Currency          string   `json:"currency"`
+	IncludeVAT        bool     `json:"include_vat"`
+	ExcludedSuppliers []string `json:"excluded_suppliers"`
+	// 0 — без ограничений
+	RateLimitPerMinute int       `json:"rate_limit_per_minute"`
+	DailyRowQuota      int       `json:"daily_row_quota"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+type UsageDay struct {
+	Day      string `json:"day"`
+	Requests int    `json:"requests"`
+	Rows     int    `json:"rows"`
+}
+
+// ================= HEALTH =================
+
+type SupplierStatus struct {
+	Name        string       `json:"name"`
+	LastSuccess *time.Time   `json:"last_success,omitempty"`
+	LastErrorAt *time.Time   `json:"last_error_at,omitempty"`
+	LastError   string       `json:"last_error,omitempty"`
+	ErrorRate   float64      `json:"error_rate"`
+	Samples     int          `json:"samples"`
+	Circuit     string       `json:"circuit"`
+	Probe       *ProbeResult `json:"probe,omitempty"`
+}
+
+type ProbeResult struct {
+	MPN       string `json:"mpn"`
+	OK        bool   `json:"ok"`
+	Found     bool   `json:"found"`
+	LatencyMs int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
 }
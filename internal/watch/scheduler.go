@@ -0,0 +1,305 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+
+	"dynamic-pricing-tool-ru/internal/logger"
+	"dynamic-pricing-tool-ru/internal/processor"
+	"dynamic-pricing-tool-ru/internal/storage"
+	"dynamic-pricing-tool-ru/internal/tracing"
+	"dynamic-pricing-tool-ru/internal/types"
+)
+
+const (
+	AlertPriceDrop = "price_drop"
+	AlertLowStock  = "low_stock"
+	AlertNewSeller = "new_seller"
+)
+
+// SellerAbsentChecks — сколько проверок подряд продавец может не
+// попадать в выдачу, прежде чем его снимок забывается: единичный пропуск
+// не сбрасывает базовую цену и не даёт повторного new_seller.
+const SellerAbsentChecks = 3
+
+// Scheduler периодически перезапрашивает поставщиков по позициям из
+// watchlist и отправляет уведомления при срабатывании порогов.
+type Scheduler struct {
+	watchlist *storage.Watchlist
+	processor *processor.Processor
+	notifier  *WebhookNotifier
+	interval  time.Duration
+
+	customers *storage.Customers
+	usage     *storage.Usage
+}
+
+func NewScheduler(watchlist *storage.Watchlist, proc *processor.Processor, notifier *WebhookNotifier, interval time.Duration) *Scheduler {
+	return &Scheduler{
+		watchlist: watchlist,
+		processor: proc,
+		notifier:  notifier,
+		interval:  interval,
+	}
+}
+
+// SetQuota включает списание проверок из дневной квоты владельца позиции:
+// каждая проверка — одна строка, как строка BOM в /process.
+func (s *Scheduler) SetQuota(customers *storage.Customers, usage *storage.Usage) {
+	s.customers = customers
+	s.usage = usage
+}
+
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.checkAll(ctx)
+		}
+	}
+}
+
+func (s *Scheduler) checkAll(ctx context.Context) {
+	items, err := s.watchlist.List(ctx)
+	if err != nil {
+		logger.L.Error("watchlist load failed", zap.Error(err))
+		return
+	}
+
+	for _, item := range items {
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err := s.check(ctx, item); err != nil {
+			logger.L.Error("watch check failed",
+				zap.Int64("watch_id", item.ID),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+func (s *Scheduler) check(ctx context.Context, item types.WatchItem) (err error) {
+	ctx, span := tracing.Start(ctx, "watch.check",
+		attribute.Int64("watch.id", item.ID),
+		attribute.String("watch.mpn", item.MPN),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	prev, err := s.watchlist.State(ctx, item.ID)
+	if err != nil {
+		return err
+	}
+
+	if ok, err := s.consumeRow(ctx, item); err != nil || !ok {
+		return err
+	}
+
+	// офферы проходят тот же путь, что строка BOM: заблокированные
+	// продавцы не дают уведомлений и не попадают в историю
+	offers, err := s.processor.SearchPart(ctx, item.MPN, item.Quantity)
+
+	// при сбое поставщика список офферов неполный: сравнение с ним дало бы
+	// ложные low_stock и new_seller, поэтому проверку пропускаем и
+	// оставляем прежний снимок
+	if err != nil {
+		logger.FromContext(ctx).Warn("watch check skipped, supplier failed",
+			zap.Int64("watch_id", item.ID),
+			zap.Error(err),
+		)
+		return nil
+	}
+
+	alerts, state := Evaluate(item, prev, offers)
+
+	if len(alerts) > 0 {
+		notification := types.WatchNotification{
+			WatchID:   item.ID,
+			MPN:       item.MPN,
+			Alerts:    alerts,
+			CheckedAt: time.Now().UTC(),
+		}
+
+		logger.FromContext(ctx).Info("watch alerts",
+			zap.Int64("watch_id", item.ID),
+			zap.Any("alerts", alerts),
+		)
+
+		if s.notifier != nil {
+			if err := s.notifier.Notify(ctx, notification); err != nil {
+				// состояние не сохраняем, чтобы повторить уведомление на следующем цикле
+				return err
+			}
+		}
+	}
+
+	return s.watchlist.SaveState(ctx, item.ID, state)
+}
+
+// consumeRow списывает проверку из квоты владельца позиции. Если квота
+// на сегодня исчерпана или владелец удалён, проверка пропускается.
+func (s *Scheduler) consumeRow(ctx context.Context, item types.WatchItem) (bool, error) {
+	if item.CustomerID == "" || s.customers == nil || s.usage == nil {
+		return true, nil
+	}
+
+	profile, err := s.customers.Get(ctx, item.CustomerID)
+	if errors.Is(err, storage.ErrNotFound) {
+		logger.FromContext(ctx).Warn("watch check skipped, customer not found",
+			zap.Int64("watch_id", item.ID),
+			zap.String("customer_id", item.CustomerID),
+		)
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	used, ok, err := s.usage.ConsumeRows(ctx, profile.ID, 1, profile.DailyRowQuota)
+	if err != nil {
+		return false, err
+	}
+	if !ok {
+		logger.FromContext(ctx).Info("watch check skipped, daily row quota exceeded",
+			zap.Int64("watch_id", item.ID),
+			zap.String("customer_id", profile.ID),
+			zap.Int("quota", profile.DailyRowQuota),
+			zap.Int("used", used),
+		)
+	}
+
+	return ok, nil
+}
+
+// Evaluate сравнивает текущие офферы с предыдущим снимком. При первой
+// проверке (prev == nil) снимок только запоминается, кроме порога по складу.
+// Падение цены считается от базовой цены продавца, а не от прошлой проверки.
+func Evaluate(item types.WatchItem, prev *storage.WatchState, offers []types.UnifiedOffer) ([]types.WatchAlert, *storage.WatchState) {
+	state := &storage.WatchState{
+		Sellers: map[string]storage.WatchSellerState{},
+	}
+
+	for _, o := range offers {
+		state.TotalStock += o.Stock
+
+		key := o.Source + "|" + o.SellerName
+		cur, ok := state.Sellers[key]
+		if !ok {
+			cur = storage.WatchSellerState{
+				SellerName: o.SellerName,
+				Source:     o.Source,
+				Currency:   o.Currency,
+			}
+		}
+
+		// цена при отслеживаемом количестве; у одного продавца может быть
+		// несколько офферов — берём лучшую
+		pb, ok := processor.PriceBreakForQty(o.PriceBreaks, item.Quantity)
+		if ok && pb.Price > 0 && (cur.Price == 0 || pb.Price < cur.Price) {
+			cur.Price = pb.Price
+			cur.Currency = o.Currency
+		}
+		cur.Stock += o.Stock
+
+		state.Sellers[key] = cur
+	}
+
+	var alerts []types.WatchAlert
+
+	if item.MinStock > 0 && state.TotalStock < item.MinStock &&
+		(prev == nil || prev.TotalStock >= item.MinStock) {
+		alerts = append(alerts, types.WatchAlert{
+			Type:      AlertLowStock,
+			MPN:       item.MPN,
+			Stock:     state.TotalStock,
+			Threshold: float64(item.MinStock),
+		})
+	}
+
+	if prev == nil {
+		for key, cur := range state.Sellers {
+			cur.BaselinePrice = cur.Price
+			state.Sellers[key] = cur
+		}
+		return alerts, state
+	}
+
+	for key, cur := range state.Sellers {
+		old, ok := prev.Sellers[key]
+
+		baseline := old.BaselinePrice
+		if baseline <= 0 {
+			// снимки, сохранённые до появления базовой цены
+			baseline = old.Price
+		}
+		if !ok || old.Currency != cur.Currency || baseline <= 0 {
+			baseline = cur.Price
+		}
+		cur.BaselinePrice = baseline
+		state.Sellers[key] = cur
+
+		if !ok {
+			if item.NotifyNewSeller {
+				alerts = append(alerts, types.WatchAlert{
+					Type:       AlertNewSeller,
+					MPN:        item.MPN,
+					SellerName: cur.SellerName,
+					Source:     cur.Source,
+					NewPrice:   cur.Price,
+					Currency:   cur.Currency,
+					Stock:      cur.Stock,
+				})
+			}
+			continue
+		}
+
+		if item.PriceDropPercent <= 0 || baseline <= 0 || cur.Price <= 0 {
+			continue
+		}
+
+		drop := (baseline - cur.Price) / baseline * 100
+		if drop >= item.PriceDropPercent {
+			alerts = append(alerts, types.WatchAlert{
+				Type:       AlertPriceDrop,
+				MPN:        item.MPN,
+				SellerName: cur.SellerName,
+				Source:     cur.Source,
+				OldPrice:   baseline,
+				NewPrice:   cur.Price,
+				Currency:   cur.Currency,
+				Stock:      cur.Stock,
+				Threshold:  item.PriceDropPercent,
+			})
+
+			// следующее падение считается уже от цены уведомления
+			cur.BaselinePrice = cur.Price
+			state.Sellers[key] = cur
+		}
+	}
+
+	// пропавший продавец остаётся в снимке с базовой ценой, пока не
+	// пропустит SellerAbsentChecks проверок подряд
+	for key, old := range prev.Sellers {
+		if _, ok := state.Sellers[key]; ok {
+			continue
+		}
+		if old.Missed+1 >= SellerAbsentChecks {
+			continue
+		}
+		old.Missed++
+		old.Stock = 0
+		state.Sellers[key] = old
+	}
+
+	return alerts, state
+}
@@ -0,0 +1,77 @@
+package watch
+
+import (
+	"testing"
+
+	"dynamic-pricing-tool-ru/internal/types"
+)
+
+func offer(seller string, breaks ...types.UnifiedPriceBreak) types.UnifiedOffer {
+	return types.UnifiedOffer{
+		Source:      "efind",
+		SellerName:  seller,
+		Currency:    "RUB",
+		Stock:       100,
+		PriceBreaks: breaks,
+	}
+}
+
+func TestEvaluatePriceAtQuantity(t *testing.T) {
+	item := types.WatchItem{MPN: "NE555DR", Quantity: 100, PriceDropPercent: 10}
+
+	// первая ступень не меняется, падает только цена от 100 штук
+	_, state := Evaluate(item, nil, []types.UnifiedOffer{
+		offer("a", types.UnifiedPriceBreak{Quantity: 1, Price: 50}, types.UnifiedPriceBreak{Quantity: 100, Price: 40}),
+	})
+	if got := state.Sellers["efind|a"].BaselinePrice; got != 40 {
+		t.Fatalf("baseline = %v, want price at 100 pcs (40)", got)
+	}
+
+	alerts, _ := Evaluate(item, state, []types.UnifiedOffer{
+		offer("a", types.UnifiedPriceBreak{Quantity: 1, Price: 50}, types.UnifiedPriceBreak{Quantity: 100, Price: 30}),
+	})
+	if len(alerts) != 1 || alerts[0].Type != AlertPriceDrop || alerts[0].OldPrice != 40 || alerts[0].NewPrice != 30 {
+		t.Fatalf("alerts = %+v, want one price_drop 40 -> 30", alerts)
+	}
+}
+
+func TestEvaluateAbsentSeller(t *testing.T) {
+	item := types.WatchItem{MPN: "NE555DR", Quantity: 1, NotifyNewSeller: true}
+	present := []types.UnifiedOffer{offer("a", types.UnifiedPriceBreak{Quantity: 1, Price: 10})}
+
+	_, state := Evaluate(item, nil, present)
+
+	// продавец пропадает меньше чем на SellerAbsentChecks проверок —
+	// при возвращении он не новый и сохраняет базовую цену
+	for i := 1; i < SellerAbsentChecks; i++ {
+		var alerts []types.WatchAlert
+		alerts, state = Evaluate(item, state, nil)
+		if len(alerts) != 0 {
+			t.Fatalf("check %d: unexpected alerts %+v", i, alerts)
+		}
+		if got := state.Sellers["efind|a"]; got.Missed != i || got.BaselinePrice != 10 {
+			t.Fatalf("check %d: seller state = %+v", i, got)
+		}
+	}
+
+	alerts, state := Evaluate(item, state, present)
+	if len(alerts) != 0 {
+		t.Fatalf("returning seller raised %+v", alerts)
+	}
+	if got := state.Sellers["efind|a"]; got.Missed != 0 {
+		t.Fatalf("missed counter not reset: %+v", got)
+	}
+
+	// после SellerAbsentChecks пропусков подряд снимок забывается
+	for i := 0; i < SellerAbsentChecks; i++ {
+		_, state = Evaluate(item, state, nil)
+	}
+	if _, ok := state.Sellers["efind|a"]; ok {
+		t.Fatalf("seller kept after %d missed checks", SellerAbsentChecks)
+	}
+
+	alerts, _ = Evaluate(item, state, present)
+	if len(alerts) != 1 || alerts[0].Type != AlertNewSeller {
+		t.Fatalf("alerts = %+v, want new_seller", alerts)
+	}
+}